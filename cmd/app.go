@@ -0,0 +1,81 @@
+// Copyright © 2016 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/30x/shipyardctl/utils"
+)
+
+// App is what the commands run against: the configuration and the HTTP
+// client used for every API call. Programs embedding RootCmd can provide
+// their own with SetApp before executing it.
+type App struct {
+	Config *utils.Config
+	Client *http.Client
+}
+
+var app *App
+var client *http.Client
+
+// SetApp sets the App used by all commands, in place of the config file
+func SetApp(a *App) {
+	app = a
+}
+
+// initApp loads the App the first time a command runs. The config file is
+// read if present, otherwise the defaults are used and only written out
+// once something, like a login, needs to be saved.
+func initApp() error {
+	if app == nil {
+		app = &App{}
+	}
+
+	// read environment variables or use defaults
+	checkEnvironmentOrDefault()
+
+	if app.Config == nil {
+		check, err := utils.ConfigExists()
+		if err != nil && debug {
+			fmt.Println("Unable to locate config file:", err)
+		}
+
+		if check {
+			app.Config, err = utils.LoadConfig()
+			if err != nil {
+				return err
+			}
+		} else {
+			app.Config = utils.MakeConfig("default", sso_target, clusterTarget)
+		}
+	}
+
+	if app.Client == nil {
+		app.Client = http.DefaultClient
+	}
+
+	config = app.Config
+	client = app.Client
+
+	// environment overrides config, so check there first before setting vars based on config
+	checkEnvironmentOrConfig()
+
+	// Enrober API path, appended to clusterTarget before each API call
+	enroberPath = "/environments"
+
+	return nil
+}
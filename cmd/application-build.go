@@ -16,20 +16,19 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
-	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
-	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
-	"bufio"
-
-	"github.com/30x/zipper"
+	"github.com/30x/shipyardctl/utils"
 	"github.com/spf13/cobra"
 )
 
@@ -83,7 +82,7 @@ func getApplications() int {
 	}
 
 	req.Header.Set("Authorization", "Bearer "+authToken)
-	response, err := http.DefaultClient.Do(req)
+	response, err := client.Do(req)
 
 	if err != nil {
 		log.Fatal(err)
@@ -166,7 +165,7 @@ func getApplication(name string, appspace string) int {
 	}
 
 	req.Header.Set("Authorization", "Bearer "+authToken)
-	response, err := http.DefaultClient.Do(req)
+	response, err := client.Do(req)
 
 	if err != nil {
 		log.Fatal(err)
@@ -189,15 +188,105 @@ var importAppCmd = &cobra.Command{
 	Use:   "application --name {name} --directory {dir} --org {org} --runtime {runtime}[:{version}]",
 	Short: "imports application into Shipyard",
 	Long: `This command is used to import an application into Shipyard
-from a given, zipped application source archive. Currently, node is the only supported runtime.
+from a given, zipped application source archive. The supported runtimes are listed by
+'shipyardctl get runtimes'.
+
+Within the project zip, there must be a valid package.json. The source is checked
+locally before upload, see 'shipyardctl validate application --help'.
 
-Within the project zip, there must be a valid package.json.
+Version control metadata, node_modules, coverage output, local .env files and .npmrc
+are left out of the upload, along with any paths matched by a .shipyardignore file
+(gitignore syntax) in the directory. Use --verbose to see what is excluded, or
+--dry-run to list what would be uploaded without importing anything.
+
+Any flags not provided default to the values in the nearest shipyard.yaml project manifest.
+Env vars can also be read from dotenv files with --env-file, where --env-var takes precedence.
 
 Example of use:
 
-$ shipyardctl import application --name "echo-app1" --directory . --org acme --runtime node:4`,
+$ shipyardctl import application --name "echo-app1" --directory . --org acme --runtime node:4
+
+The build result can be written as JSON or YAML for scripts, and the new revision
+saved to a file for later deploy steps:
+
+$ shipyardctl import application -n echo-app1 -d . -o acme --format json --output-revision-file rev.txt
+
+Instead of a directory, the tree of a commit in a local git repository can be
+imported, ignoring any uncommitted changes. The commit sha is recorded with the revision:
+
+$ shipyardctl import application -n echo-app1 -o acme --git . --ref v1.2.0
+
+An archive produced by another build system can be imported as is. Zip files are
+uploaded unchanged and tarballs converted to a zip. Either way package.json must be
+at the root of the archive, not under a base directory:
+
+$ shipyardctl import application -n echo-app1 -o acme --archive build/echo-app1.tar.gz
+
+The import waits for the build to finish. With --detach it returns once the source
+is uploaded, printing a build id to check on later with 'shipyardctl get build'
+or 'shipyardctl logs build --follow':
+
+$ shipyardctl import application -n echo-app1 -d . -o acme --detach
+
+Production dependencies can be installed before packaging with --install. The
+source is copied to a staging directory, along with any .npmrc and lockfiles, and the
+install run there, so the working directory is left untouched. The .npmrc is removed
+again before packaging, and the installed dependencies aren't warned about. By default
+'yarn install --production --frozen-lockfile' is run when there is a yarn.lock, and
+'npm install --production' otherwise. Another command can be given instead:
+
+$ shipyardctl import application -n echo-app1 -d . -o acme --install
+$ shipyardctl import application -n echo-app1 -d . -o acme --install="npm ci --only=production"
+
+Before upload, the size of the archive is reported along with any common mistakes,
+such as including .git, coverage output or compiled dependencies. Use --verbose to
+also list the largest files and directories. Archives over the max archive size of
+the current context are refused unless --ignore-size-limit is given.
+
+Several applications can be imported together, at most --parallel at a time, from
+directories or globs of them given with --apps, or listed under "apps" in the project
+manifest. Each is named by the shipyard.yaml in its directory, if any, or after the
+directory. A summary of the revision or error of each app is printed, and the command
+fails if any of them does:
+
+$ shipyardctl import application -o acme --apps 'services/*' --parallel 4
+
+Every import records a hash of the packaged files, runtime and env vars. With
+--skip-unchanged, the latest revision is reused when its hash matches:
+
+$ shipyardctl import application -n echo-app1 -d . -o acme --skip-unchanged`,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if err := RequireAuthToken(); err != nil {
+		if format != "" && format != "json" && format != "yaml" {
+			return fmt.Errorf("Unsupported output format %q, use json or yaml.", format)
+		}
+
+		if !dryRun {
+			if err := RequireAuthToken(); err != nil {
+				return err
+			}
+		}
+
+		if err := LoadEnvFiles(); err != nil {
+			return err
+		}
+
+		// each app's import applies its own manifest
+		if multi, err := isMultiAppImport(cmd); err != nil {
+			return err
+		} else if multi {
+			if gitRepo != "" || archivePath != "" || dryRun || outputRevisionFile != "" {
+				return fmt.Errorf("'--git', '--archive', '--dry-run' and '--output-revision-file' import a single application.")
+			}
+
+			if err := RequireOrgName(); err != nil {
+				return err
+			}
+
+			MakeBuildPath()
+			return nil
+		}
+
+		if err := ApplyManifestDefaults(cmd); err != nil {
 			return err
 		}
 
@@ -209,7 +298,23 @@ $ shipyardctl import application --name "echo-app1" --directory . --org acme --r
 			return err
 		}
 
-		if err := RequireDirectory(); err != nil {
+		if detach && outputRevisionFile != "" {
+			return fmt.Errorf("'--output-revision-file' can't be combined with '--detach', use it with 'logs build --follow' instead.")
+		}
+
+		if installCommand != "" && archivePath != "" {
+			return fmt.Errorf("'--install' can't be combined with '--archive', prebuilt archives are imported as is.")
+		}
+
+		if gitRepo != "" && archivePath != "" {
+			return fmt.Errorf("Only one of '--git' or '--archive' may be given.")
+		}
+
+		if gitRepo != "" || archivePath != "" {
+			if cmd.Flags().Changed("directory") {
+				return fmt.Errorf("'--directory' can't be combined with '--git' or '--archive'.")
+			}
+		} else if err := RequireDirectory(); err != nil {
 			return err
 		}
 
@@ -218,10 +323,17 @@ $ shipyardctl import application --name "echo-app1" --directory . --org acme --r
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		status := importApp(appName, directory)
+		if multi, _ := isMultiAppImport(cmd); multi {
+			importMultipleApps(cmd)
+			return
+		}
+
+		status, err := importApp(newImportJob())
+		checkImportError(err)
 		if !CheckIfAuthn(status) {
 			// retry once more
-			status = importApp(appName, directory)
+			status, err = importApp(newImportJob())
+			checkImportError(err)
 			if status == 401 {
 				fmt.Println("Unable to authenticate. Please check your SSO target URL is correct.")
 				fmt.Println("Command failed.")
@@ -230,101 +342,380 @@ $ shipyardctl import application --name "echo-app1" --directory . --org acme --r
 	},
 }
 
-func importApp(appName string, directory string) int {
-	tmpdir, err := ioutil.TempDir("", appName)
+// importJob the settings of a single application's import, and its outcome
+type importJob struct {
+	Name      string
+	Directory string
+	Runtime   string
+	EnvVars   []string
+	Install   string // the install command, as given
+
+	Out      io.Writer // progress and reports
+	Results  io.Writer // build results
+	Progress bool      // render an upload progress bar
+
+	Result  *BuildResult // the built or reused revision
+	BuildID string       // the build started, when detached
+	Failure string       // why the import was refused or failed, when already reported
+}
+
+// newImportJob an import of the application named on the command line
+func newImportJob() *importJob {
+	return &importJob{
+		Name:      appName,
+		Directory: directory,
+		Runtime:   runtime,
+		EnvVars:   envVars,
+		Install:   installCommand,
+		Out:       humanOutput(),
+		Results:   os.Stdout,
+		Progress:  true,
+	}
+}
+
+func importApp(job *importJob) (int, error) {
+	if job.Runtime == "" {
+		job.Runtime = DefaultRuntime
+	}
+
+	if err := validateRuntime(job.Runtime); err != nil {
+		fmt.Fprintln(job.Out, err)
+		fmt.Fprintln(job.Out, "See 'shipyardctl get runtimes'. Exiting")
+		job.Failure = err.Error()
+		return -1, nil
+	}
+
+	tmpdir, err := ioutil.TempDir("", job.Name)
 	if err != nil {
-		log.Fatal(err)
+		return 0, err
 	}
 
 	defer os.RemoveAll(tmpdir)
-	zipPath := filepath.Join(tmpdir, appName+".zip")
+	metadata := map[string]string{}
+	directory := job.Directory
 
-	err = zipper.ArchiveUnprocessed(directory, zipPath, zipper.Options{
-		ExcludeBaseDir: true,
-	})
+	// package the committed tree at the ref, not the working copy
+	if gitRepo != "" {
+		commit, err := gitCommit(gitRepo, gitRef)
+		if err != nil {
+			return 0, err
+		}
 
-	if err != nil {
-		log.Fatal(err)
+		directory = filepath.Join(tmpdir, "source")
+		if err = gitArchive(gitRepo, commit, directory); err != nil {
+			return 0, err
+		}
+
+		fmt.Fprintf(job.Out, "Packaging %s at commit %s\n", gitRepo, commit)
+		metadata["gitCommit"] = commit
+	}
+
+	var files []packageFile
+	var excluded []excludedPath
+
+	// prebuilt archives are uploaded with all of their contents
+	if archivePath != "" {
+		directory = filepath.Join(tmpdir, "source")
+		if err = extractArchive(archivePath, directory); err != nil {
+			return 0, err
+		}
+
+		files, excluded, err = walkPackageFiles(directory, utils.NewIgnoreMatcher(nil))
+	} else {
+		files, excluded, err = listPackageFiles(directory)
 	}
 
-	zip, err := os.Open(zipPath)
 	if err != nil {
-		log.Fatal(err)
+		return 0, err
+	}
+
+	if verbose {
+		printExcludedPaths(job.Out, excluded)
+	}
+
+	if !skipValidation {
+		report := validateNodeApp(files, job.Runtime)
+		if len(report.Errors) > 0 || len(report.Warnings) > 0 {
+			out, err := formatValidationReport(report, "human")
+			if err != nil {
+				return 0, err
+			}
+
+			fmt.Fprint(job.Out, string(out))
+		}
+
+		if !report.Valid {
+			fmt.Fprintln(job.Out, "Fix the above errors or use --skip-validation to import anyway.")
+			job.Failure = report.Errors[0].Message
+			return -1, nil
+		}
+	}
+
+	install := ""
+	if job.Install != "" {
+		install = resolveInstallCommand(job.Install, files)
 	}
-	defer zip.Close()
 
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-	part, err := writer.CreateFormFile("file", filepath.Base(zipPath))
+	hash, err := contentHash(files, job.Runtime, job.EnvVars, install)
 	if err != nil {
-		log.Fatal(err)
+		return 0, err
+	}
+	metadata["contentHash"] = hash
+
+	zipPath := filepath.Join(tmpdir, job.Name+".zip")
+	if archivePath != "" && isZipArchive(archivePath) {
+		zipPath = archivePath
+	}
+
+	if dryRun {
+		if zipPath != archivePath {
+			if err = writePackage(files, zipPath); err != nil {
+				return 0, err
+			}
+		}
+
+		analysis, err := analyzeArchive(zipPath, false)
+		if err != nil {
+			return 0, err
+		}
+
+		printPackageFiles(files)
+		fmt.Println()
+		printArchiveAnalysis(os.Stdout, analysis, true)
+		if install != "" {
+			fmt.Println("Dependencies would be installed with:", install)
+		}
+		fmt.Println("Content hash:", hash)
+		fmt.Println()
+
+		importURL, fields := importForm(job, metadata)
+		if err = printDryRunUpload("POST", importURL, fields, zipPath); err != nil {
+			return 0, err
+		}
+
+		return 0, nil
+	}
+
+	if skipUnchanged {
+		latest, status, err := getLatestRevision(job.Name)
+		if err != nil {
+			return 0, err
+		}
+
+		if status == 401 {
+			return status, nil
+		}
+
+		if latest != nil && latest.Metadata["contentHash"] == hash {
+			fmt.Fprintf(job.Out, "Sources unchanged since revision %s, skipping import.\n", latest.Revision)
+			job.Result = &BuildResult{
+				Succeeded:    true,
+				Reused:       true,
+				Organization: orgName,
+				Application:  job.Name,
+				Revision:     latest.Revision,
+				Phases:       []BuildPhase{},
+				Metadata:     metadata,
+			}
+
+			return 200, printBuildResult(job.Results, job.Result)
+		}
 	}
-	_, err = io.Copy(part, zip)
 
-	if len(envVars) > 0 {
-		for i := range envVars {
-			writer.WriteField("envVar", envVars[i])
+	// install into a copy, leaving the working directory untouched
+	if install != "" {
+		fmt.Fprintf(job.Out, "Installing dependencies with: %s\n", install)
+		if files, err = stageInstall(files, directory, install, filepath.Join(tmpdir, "stage"), job.Out); err != nil {
+			return 0, err
 		}
 	}
 
-	if runtime == "" {
-		runtime = DefaultRuntime
+	if zipPath != archivePath {
+		if err = writePackage(files, zipPath); err != nil {
+			return 0, err
+		}
 	}
 
-	runtimeSplit := strings.Split(runtime, ":")
+	analysis, err := analyzeArchive(zipPath, install != "")
+	if err != nil {
+		return 0, err
+	}
 
-	if !isSupportedRuntime(runtimeSplit[0]) {
-		fmt.Printf("Provided runtime: \"%s\"\n", runtimeSplit[0])
-		fmt.Printf("Supported runtimes: \"%s\"\n", supportedRuntimes)
-		fmt.Println("Exiting")
-		return -1
+	if err = checkArchiveSize(analysis); err != nil {
+		printArchiveAnalysis(job.Out, analysis, true)
+		return 0, err
 	}
 
-	writer.WriteField("name", appName)
-	writer.WriteField("runtime", runtime)
+	printArchiveAnalysis(job.Out, analysis, verbose)
+
+	importURL, fields := importForm(job, metadata)
+	response, err := uploadArchive(importURL, zipPath, fields, job.Out, job.Progress)
 
-	err = writer.Close()
 	if err != nil {
-		log.Fatal(err)
+		return 0, err
+	}
+
+	if debug {
+		PrintDebugResponse(response)
 	}
 
-	req, err := http.NewRequest("POST", clusterTarget+basePath, body)
+	// dump response to stdout
+	defer response.Body.Close()
+	if response.StatusCode == 202 {
+		build := &buildStatus{}
+		if err = json.NewDecoder(response.Body).Decode(build); err != nil {
+			return 0, err
+		}
+
+		job.BuildID = build.ID
+		return response.StatusCode, printDetachedBuild(job.Results, job.Name, build)
+	} else if response.StatusCode == 201 {
+		fmt.Fprintln(job.Out, "\nBeginning application import. This could take a minute.")
+
+		var stream io.Writer
+		if verbose {
+			stream = job.Out
+		}
+
+		hist := startHistoryLog(utils.HistoryBuilds, job.Name)
+		result, err := handleBuildStream(hist.Tee(response.Body), stream)
+		hist.Finish("", result.Revision, err == nil)
+
+		if result != nil && len(metadata) > 0 {
+			result.Metadata = metadata
+		}
+		if result != nil && (format != "" || err == nil) {
+			job.Result = result
+			if printErr := printBuildResult(job.Results, result); printErr != nil {
+				return 0, printErr
+			}
+		}
+
+		if err != nil {
+			return 0, err
+		}
+	} else if response.StatusCode != 401 {
+		var body bytes.Buffer
+		if _, err = io.Copy(job.Results, io.TeeReader(response.Body, &body)); err != nil {
+			return 0, err
+		}
+
+		job.Failure = response.Status
+		if line := errorLine(body.String()); line != "" {
+			job.Failure += ": " + line
+		}
+	}
+
+	return response.StatusCode, nil
+}
+
+// checkImportError exits when a single application's import failed
+func checkImportError(err error) {
 	if err != nil {
 		log.Fatal(err)
 	}
+}
+
+// appRevision an imported revision of an application
+type appRevision struct {
+	Revision string
+	Metadata map[string]string
+}
+
+// getLatestRevision retrieves the highest revision of the named application,
+// or nil if it was never imported. It also returns the response status.
+func getLatestRevision(name string) (*appRevision, int, error) {
+	revisions, status, err := getAppRevisions(name)
+	if err != nil || len(revisions) == 0 {
+		return nil, status, err
+	}
+
+	return &revisions[len(revisions)-1], status, nil
+}
+
+// getAppRevisions retrieves the revisions of the named application, lowest first,
+// along with the response status. None are returned if it was never imported.
+func getAppRevisions(name string) ([]appRevision, int, error) {
+	req, err := http.NewRequest("GET", clusterTarget+basePath+"/"+name, nil)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	if debug {
 		PrintDebugRequest(req)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+authToken)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-	response, err := http.DefaultClient.Do(req)
-
+	response, err := client.Do(req)
 	if err != nil {
-		log.Fatal(err)
+		return nil, 0, err
 	}
 
 	if debug {
 		PrintDebugResponse(response)
 	}
 
-	// dump response to stdout
 	defer response.Body.Close()
-	if response.StatusCode == 201 {
-		fmt.Println("\nBeginning application import. This could take a minute.")
-		err = handleBuildStream(response.Body, verbose)
+
+	switch response.StatusCode {
+	case 200:
+	case 401:
+		return nil, response.StatusCode, nil
+	case 404:
+		return nil, response.StatusCode, nil // never imported
+	default:
+		return nil, response.StatusCode, fmt.Errorf("There was an error retrieving %s: %s", name, response.Status)
+	}
+
+	var raw []map[string]interface{}
+	if err = json.NewDecoder(response.Body).Decode(&raw); err != nil {
+		return nil, response.StatusCode, err
+	}
+
+	var revisions []appRevision
+	for _, rev := range raw {
+		num, err := strconv.Atoi(fmt.Sprint(rev["revision"]))
 		if err != nil {
-			log.Fatal(err)
+			continue
 		}
-	} else if response.StatusCode != 401 {
-		_, err = io.Copy(os.Stdout, response.Body)
-		if err != nil {
-			log.Fatal(err)
+
+		revisions = append(revisions, appRevision{strconv.Itoa(num), parseRevisionMetadata(rev["metadata"])})
+	}
+
+	sort.Sort(byRevision(revisions))
+	return revisions, response.StatusCode, nil
+}
+
+type byRevision []appRevision
+
+func (r byRevision) Len() int      { return len(r) }
+func (r byRevision) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r byRevision) Less(i, j int) bool {
+	a, _ := strconv.Atoi(r[i].Revision)
+	b, _ := strconv.Atoi(r[j].Revision)
+	return a < b
+}
+
+// parseRevisionMetadata reads revision metadata returned either as an object
+// or as the "key=value" strings it was sent as
+func parseRevisionMetadata(raw interface{}) map[string]string {
+	metadata := map[string]string{}
+
+	switch m := raw.(type) {
+	case map[string]interface{}:
+		for k, v := range m {
+			metadata[k] = fmt.Sprint(v)
+		}
+	case []interface{}:
+		for _, pair := range m {
+			split := strings.SplitN(fmt.Sprint(pair), "=", 2)
+			if len(split) == 2 {
+				metadata[split[0]] = split[1]
+			}
 		}
 	}
 
-	return response.StatusCode
+	return metadata
 }
 
 var deleteAppCmd = &cobra.Command{
@@ -338,8 +729,10 @@ Example of use:
 
 $ shipyardctl delete application -n example:1 --org org1`,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if err := RequireAuthToken(); err != nil {
-			return err
+		if !dryRun {
+			if err := RequireAuthToken(); err != nil {
+				return err
+			}
 		}
 
 		if err := RequireAppName(); err != nil {
@@ -362,7 +755,7 @@ $ shipyardctl delete application -n example:1 --org org1`,
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 
-		if !force {
+		if !force && !dryRun {
 			promptResponse, err := PromptAppDeletion(appName)
 			if err != nil {
 				log.Fatal(err)
@@ -372,7 +765,7 @@ $ shipyardctl delete application -n example:1 --org org1`,
 				fmt.Println("Chose to cancel. Aborting.")
 				return
 			}
-		} else {
+		} else if force {
 			shipyardEnv := orgName + ":" + envName
 			fmt.Printf("Undeploying any active deployment of %s in %s\n", appName, shipyardEnv)
 
@@ -394,14 +787,39 @@ $ shipyardctl delete application -n example:1 --org org1`,
 	},
 }
 
+// importForm builds the URL and form fields an application archive is uploaded with
+func importForm(job *importJob, metadata map[string]string) (string, []formField) {
+	fields := []formField{}
+	for i := range job.EnvVars {
+		fields = append(fields, formField{"envVar", job.EnvVars[i]})
+	}
+
+	fields = append(fields, formField{"name", job.Name}, formField{"runtime", job.Runtime})
+
+	for _, key := range sortedKeys(metadata) {
+		fields = append(fields, formField{"metadata", key + "=" + metadata[key]})
+	}
+
+	importURL := clusterTarget + basePath
+	if detach {
+		importURL += "?detach=true"
+	}
+
+	return importURL, fields
+}
+
 func deleteApp(appName string) int {
 	req, err := http.NewRequest("DELETE", clusterTarget+basePath+"/"+appName, nil)
+	if skipForDryRun(req, nil) {
+		return dryRunStatus
+	}
+
 	if debug {
 		PrintDebugRequest(req)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+authToken)
-	response, err := http.DefaultClient.Do(req)
+	response, err := client.Do(req)
 
 	if err != nil {
 		log.Fatal(err)
@@ -436,12 +854,27 @@ func init() {
 	getApplicationCmd.Flags().StringVar(&format, "format", "", "output format: json,yaml,raw")
 
 	importCmd.AddCommand(importAppCmd)
-	importAppCmd.Flags().StringSliceVar(&envVars, "env-var", []string{}, "Environment variable to set in the built image \"KEY=VAL\" ")
+	importAppCmd.Flags().StringArrayVar(&envVars, "env-var", []string{}, "Environment variable to set in the built image \"KEY=VAL\", may be repeated")
+	importAppCmd.Flags().StringArrayVar(&envFiles, "env-file", []string{}, "dotenv file of environment variables to set in the built image, may be repeated")
 	importAppCmd.Flags().StringVarP(&orgName, "org", "o", "", "Apigee org name")
 	importAppCmd.Flags().StringVarP(&runtime, "runtime", "u", "node:4", "Runtime to use for application and optional version, ex. node[:5]")
 	importAppCmd.Flags().StringVarP(&appName, "name", "n", "", "application name and optional revision")
 	importAppCmd.Flags().StringVarP(&directory, "directory", "d", "", "directory of application source archive")
-	importAppCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "stream build output to console")
+	importAppCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "stream build output to console and list excluded files")
+	importAppCmd.Flags().StringVar(&gitRepo, "git", "", "local git repository to import the tree of --ref from, instead of --directory")
+	importAppCmd.Flags().StringVar(&gitRef, "ref", "HEAD", "commit sha, tag or branch to import with --git")
+	importAppCmd.Flags().StringVar(&archivePath, "archive", "", "prebuilt .zip, .tar.gz or .tgz application archive to import, instead of --directory")
+	importAppCmd.Flags().StringVar(&format, "format", "", "output format for the build result: json,yaml")
+	importAppCmd.Flags().StringVar(&outputRevisionFile, "output-revision-file", "", "file to write the built revision to")
+	importAppCmd.Flags().IntVar(&uploadRetries, "upload-retries", 2, "times to retry the upload from the start when the connection fails")
+	importAppCmd.Flags().StringArrayVar(&importApps, "apps", []string{}, "directory, or glob of directories, of applications to import together, may be repeated")
+	importAppCmd.Flags().IntVar(&importParallel, "parallel", 4, "number of applications imported at a time with --apps")
+	importAppCmd.Flags().StringVar(&installCommand, "install", "", "install production dependencies into a copy of the source before packaging, with the given command or one picked from the lockfile")
+	importAppCmd.Flags().Lookup("install").NoOptDefVal = installAuto
+	importAppCmd.Flags().BoolVar(&ignoreSizeLimit, "ignore-size-limit", false, "import even when the archive is larger than the context's max archive size")
+	importAppCmd.Flags().BoolVar(&detach, "detach", false, "return once the source is uploaded, without waiting for the build")
+	importAppCmd.Flags().BoolVar(&skipUnchanged, "skip-unchanged", false, "reuse the latest revision instead of importing when its content hash matches")
+	importAppCmd.Flags().BoolVar(&skipValidation, "skip-validation", false, "import without checking the application source first")
 
 	deleteCmd.AddCommand(deleteAppCmd)
 	deleteAppCmd.Flags().StringVarP(&orgName, "org", "o", "", "Apigee org name")
@@ -449,44 +882,3 @@ func init() {
 	deleteAppCmd.Flags().StringVarP(&appName, "name", "n", "", "Name of application to be deleted")
 	deleteAppCmd.Flags().BoolVar(&force, "force", false, "forces the deletion of all app revisions and any active deployments")
 }
-
-func isSupportedRuntime(input string) bool {
-	return strings.Contains(supportedRuntimes, input)
-}
-
-func handleBuildStream(stream io.ReadCloser, outputToConsole bool) error {
-	var line string
-	var data bytes.Buffer
-	scanner := bufio.NewScanner(stream)
-
-	for scanner.Scan() {
-		line = scanner.Text()
-
-		if outputToConsole {
-			fmt.Println(line)
-		}
-
-		if !outputToConsole {
-			data.WriteString(line + "\n")
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return err
-	}
-
-	if match, _ := regexp.MatchString("Organization: [a-z0-9]+ | Application: [a-z0-9]+ | Revision: [a-z0-9]+", line); !match {
-		if !outputToConsole {
-			return fmt.Errorf("There was a problem during the build. Build output:\n%s\nPlease refer to the above build output", data.String())
-		}
-
-		// else
-		return fmt.Errorf("There was a problem during the build. Refer to the build stream")
-	}
-
-	if !outputToConsole {
-		fmt.Println(line) // print last scanned line
-	}
-
-	return nil
-}
@@ -24,6 +24,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -35,7 +36,8 @@ type EnvVar struct {
 }
 
 type EnVarSource struct {
-	EdgeConfigRef ConfigRef `json:"edgeConfigRef,omitempty"`
+	EdgeConfigRef ConfigRef       `json:"edgeConfigRef,omitempty"`
+	raw           json.RawMessage // any other source, passed through as it was read
 }
 
 type ConfigRef struct {
@@ -138,7 +140,7 @@ func getDeploymentNamed(envName string, depName string) int {
 	}
 
 	req.Header.Set("Authorization", "Bearer "+authToken)
-	response, err := http.DefaultClient.Do(req)
+	response, err := client.Do(req)
 
 	if err != nil {
 		log.Fatal(err)
@@ -165,7 +167,7 @@ func getDeploymentAll(envName string) int {
 	}
 
 	req.Header.Set("Authorization", "Bearer "+authToken)
-	response, err := http.DefaultClient.Do(req)
+	response, err := client.Do(req)
 
 	if err != nil {
 		log.Fatal(err)
@@ -193,8 +195,10 @@ this will undeploy it.
 Example of use:
 $ shipyardctl undeploy application -n example -o acme -e test`,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if err := RequireAuthToken(); err != nil {
-			return err
+		if !dryRun {
+			if err := RequireAuthToken(); err != nil {
+				return err
+			}
 		}
 
 		if err := RequireAppName(); err != nil {
@@ -227,14 +231,24 @@ $ shipyardctl undeploy application -n example -o acme -e test`,
 }
 
 func undeployApplication(envName string, depName string) int {
+	return undeployApplicationFrom(envName, depName, nil)
+}
+
+// undeployApplicationFrom removes the deployment. before digests its live
+// environment variables, or is nil when they aren't known.
+func undeployApplicationFrom(envName string, depName string, before map[string]string) int {
 	// build API call URL
 	req, err := http.NewRequest("DELETE", clusterTarget+enroberPath+"/"+envName+"/deployments/"+depName, nil)
+	if skipForDryRun(req, nil) {
+		return dryRunStatus
+	}
+
 	if debug {
 		PrintDebugRequest(req)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+authToken)
-	response, err := http.DefaultClient.Do(req)
+	response, err := client.Do(req)
 
 	if err != nil {
 		log.Fatal(err)
@@ -247,10 +261,12 @@ func undeployApplication(envName string, depName string) int {
 	// dump response body to stdout
 	defer response.Body.Close()
 
+	body := recordDeployment(envName, depName, "", before, nil, nil, response)
+
 	success := fmt.Sprintf("Undeployment of %s in %s was successful", depName, envName)
 	failure := fmt.Sprintf("There was a problem undeploying %s in %s", depName, envName)
 
-	outputBasedOnStatus(success, failure, response.Body, response.StatusCode, format)
+	outputBasedOnStatus(success, failure, body, response.StatusCode, format)
 
 	return response.StatusCode
 }
@@ -268,25 +284,55 @@ This command can also update an active deployment, with the --force flag.
 #Update application reivision
 $ shipyardctl deploy application -o acme -e test -n example:5 --force
 
+#Deploy with three replicas
+$ shipyardctl deploy application -o acme -e test -n example:4 --replicas 3
+
 #Update environment variable
 $ shipyardctl deploy application -o acme -e test -n example --force --env-var="EXISTING_KEY=NEW_VAL"
 
+#Set environment variables from a dotenv file, --env-var takes precedence
+$ shipyardctl deploy application -o acme -e test -n example:4 --env-file .env.test --env-var="LOG_LEVEL=debug"
+
 #Force fresh deployment of an active revision, a.k.a bouncing a deployment
-$ shipyardctl deploy application -o acme -e test -n example --force`,
+$ shipyardctl deploy application -o acme -e test -n example --force
+
+#Wait for the new revision to have its minimum replicas available
+$ shipyardctl deploy application -o acme -e test -n example:5 --force --wait --timeout 10m
+
+Env vars, edge configs and replicas declared for the environment in the nearest
+shipyard.yaml project manifest are used unless overridden by flags.`,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if err := RequireAuthToken(); err != nil {
+		if !dryRun {
+			if err := RequireAuthToken(); err != nil {
+				return err
+			}
+		}
+
+		if err := RequireOrgName(); err != nil {
 			return err
 		}
 
-		if err := RequireAppName(); err != nil {
+		if err := RequireEnvName(); err != nil {
 			return err
 		}
 
-		if err := RequireOrgName(); err != nil {
+		if err := LoadEnvFiles(); err != nil {
 			return err
 		}
 
-		if err := RequireEnvName(); err != nil {
+		if err := ApplyManifestDefaults(cmd); err != nil {
+			return err
+		}
+
+		if err := validateEdgeConfigs(edgeConfigs); err != nil {
+			return err
+		}
+
+		if err := RequireAppName(); err != nil {
+			return err
+		}
+
+		if err := RequireReplicas(1); err != nil {
 			return err
 		}
 
@@ -296,7 +342,7 @@ $ shipyardctl deploy application -o acme -e test -n example --force`,
 		vars := parseEnvVars()
 		vars = append(vars, parseConfigRefs()...)
 		shipyardEnv := orgName + ":" + envName
-		replicas32 := int32(defaultReplicas)
+		replicas32 := int32(replicas)
 
 		nameSplit := strings.Split(appName, ":")
 
@@ -318,15 +364,27 @@ $ shipyardctl deploy application -o acme -e test -n example --force`,
 				updateData.EnvVars = vars
 			}
 
+			// only rescale an active deployment when asked to
+			if cmd.Flags().Changed("replicas") {
+				updateData.Replicas = &replicas32
+			}
+
 			status := updateDeployment(shipyardEnv, nameSplit[0], updateData)
 			if !CheckIfAuthn(status) {
 				// retry once more
-				status := updateDeployment(shipyardEnv, nameSplit[0], updateData)
+				status = updateDeployment(shipyardEnv, nameSplit[0], updateData)
 				if status == 401 {
 					fmt.Println("Unable to authenticate. Please check your SSO target URL is correct.")
 					fmt.Println("Command failed.")
 				}
 			}
+
+			revision := ""
+			if updateData.Revision != nil {
+				revision = fmt.Sprint(*updateData.Revision)
+			}
+
+			waitOrExit(status, shipyardEnv, nameSplit[0], revision, -1)
 		} else {
 			if len(nameSplit) < 2 {
 				fmt.Println("Missing required revision number.")
@@ -343,12 +401,14 @@ $ shipyardctl deploy application -o acme -e test -n example --force`,
 			status := deployApplication(shipyardEnv, nameSplit[NAME], revision32, replicas32, vars)
 			if !CheckIfAuthn(status) {
 				// retry once more
-				status := deployApplication(envName, nameSplit[NAME], revision32, replicas32, vars)
+				status = deployApplication(shipyardEnv, nameSplit[NAME], revision32, replicas32, vars)
 				if status == 401 {
 					fmt.Println("Unable to authenticate. Please check your SSO target URL is correct.")
 					fmt.Println("Command failed.")
 				}
 			}
+
+			waitOrExit(status, shipyardEnv, nameSplit[NAME], nameSplit[1], -1)
 		}
 	},
 }
@@ -362,6 +422,9 @@ func deployApplication(envName string, depName string, revision int32, replicas
 
 	// build API call with request body (deployment information)
 	req, err := http.NewRequest("POST", clusterTarget+enroberPath+"/"+envName+"/deployments", bytes.NewBuffer(js))
+	if skipForDryRun(req, js) {
+		return dryRunStatus
+	}
 
 	if debug {
 		PrintDebugRequest(req)
@@ -369,7 +432,7 @@ func deployApplication(envName string, depName string, revision int32, replicas
 
 	req.Header.Set("Authorization", "Bearer "+authToken)
 	req.Header.Set("Content-Type", "application/json")
-	response, err := http.DefaultClient.Do(req)
+	response, err := client.Do(req)
 
 	if err != nil {
 		log.Fatal(err)
@@ -382,21 +445,32 @@ func deployApplication(envName string, depName string, revision int32, replicas
 	// dump response to stdout
 	defer response.Body.Close()
 
+	body := recordDeployment(envName, depName, fmt.Sprint(revision), envDigests(nil), vars, js, response)
+
 	success := fmt.Sprintf("Creation of %s in %s was successful", depName, envName)
 	failure := fmt.Sprintf("There was a problem deploying %s in %s", depName, envName)
 
-	outputBasedOnStatus(success, failure, response.Body, response.StatusCode, format)
+	outputBasedOnStatus(success, failure, body, response.StatusCode, format)
 
 	return response.StatusCode
 }
 
 func updateDeployment(envName string, depName string, updateData deploymentPatch) int {
+	return updateDeploymentFrom(envName, depName, updateData, nil)
+}
+
+// updateDeploymentFrom patches the deployment. before digests its live environment
+// variables ahead of the change, or is nil when they aren't known.
+func updateDeploymentFrom(envName string, depName string, updateData deploymentPatch, before map[string]string) int {
 	data, err := json.Marshal(updateData)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	req, err := http.NewRequest("PATCH", clusterTarget+enroberPath+"/"+envName+"/deployments/"+depName, bytes.NewBuffer(data))
+	if skipForDryRun(req, data) {
+		return dryRunStatus
+	}
 
 	req.Header.Set("Authorization", "Bearer "+authToken)
 	if debug {
@@ -404,7 +478,7 @@ func updateDeployment(envName string, depName string, updateData deploymentPatch
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	response, err := http.DefaultClient.Do(req)
+	response, err := client.Do(req)
 
 	if err != nil {
 		log.Fatal(err)
@@ -416,10 +490,17 @@ func updateDeployment(envName string, depName string, updateData deploymentPatch
 
 	defer response.Body.Close()
 
+	revision := ""
+	if updateData.Revision != nil {
+		revision = fmt.Sprint(*updateData.Revision)
+	}
+
+	body := recordDeployment(envName, depName, revision, before, updateData.EnvVars, data, response)
+
 	success := fmt.Sprintf("Update of %s in %s was successful", depName, envName)
 	failure := fmt.Sprintf("There was a problem updating %s in %s", depName, envName)
 
-	outputBasedOnStatus(success, failure, response.Body, response.StatusCode, format)
+	outputBasedOnStatus(success, failure, body, response.StatusCode, format)
 
 	return response.StatusCode
 }
@@ -480,7 +561,7 @@ func getDeploymentLogs(envName string, depName string) int {
 	}
 
 	req.Header.Set("Authorization", "Bearer "+authToken)
-	response, err := http.DefaultClient.Do(req)
+	response, err := client.Do(req)
 
 	if err != nil {
 		log.Fatal(err)
@@ -523,44 +604,46 @@ func init() {
 	undeployApplicationCmd.Flags().StringVarP(&appName, "name", "n", "", "name of application deployment to undeploy")
 
 	deployCmd.AddCommand(deployApplicationCmd)
-	deployApplicationCmd.Flags().StringSliceVar(&envVars, "env-var", []string{}, "Environment variables to set in the deployment")
+	deployApplicationCmd.Flags().StringArrayVar(&envVars, "env-var", []string{}, "Environment variable to set in the deployment \"KEY=VAL\", may be repeated")
+	deployApplicationCmd.Flags().StringArrayVar(&envFiles, "env-file", []string{}, "dotenv file of environment variables to set in the deployment, may be repeated")
 	deployApplicationCmd.Flags().StringVarP(&orgName, "org", "o", "", "Apigee organization name")
 	deployApplicationCmd.Flags().StringVarP(&envName, "env", "e", "", "Apigee environment name")
 	deployApplicationCmd.Flags().StringVarP(&appName, "name", "n", "", "name and revision of application to deploy, ex. \"hello:3\"")
 	deployApplicationCmd.Flags().StringSliceVar(&edgeConfigs, "edge-config", []string{}, "Edge-based configuration value exposed in deployment")
 	deployApplicationCmd.Flags().BoolVar(&force, "force", false, "used to force an update of an active deployment")
 	deployApplicationCmd.Flags().StringVar(&format, "format", "", "output format for response: json, yaml, raw")
+	deployApplicationCmd.Flags().IntVar(&replicas, "replicas", defaultReplicas, "number of replicas to run, only changed on an active deployment with --force when given")
+	deployApplicationCmd.Flags().BoolVar(&wait, "wait", false, "wait until the deployment has its minimum replicas available at the deployed revision")
+	deployApplicationCmd.Flags().DurationVar(&waitTimeout, "timeout", 5*time.Minute, "how long to --wait before failing")
 
 }
 
-func parseEnvVars() (parsed []EnvVar) {
-	var temp string
+func parseEnvVars() []EnvVar {
+	return envVarsFromPairs(envVars)
+}
 
-	if len(envVars) > 0 {
-		for i := range envVars {
-			temp = envVars[i]
-			split := strings.Split(temp, "=")
-			parsed = append(parsed, EnvVar{Name: split[NAME], Value: split[VALUE]})
-		}
-	} else {
-		return []EnvVar{}
+func parseConfigRefs() []EnvVar {
+	return configRefsFromPairs(edgeConfigs)
+}
+
+// envVarsFromPairs converts "NAME=VAL" pairs into env vars
+func envVarsFromPairs(pairs []string) []EnvVar {
+	parsed := []EnvVar{}
+	for _, pair := range pairs {
+		split := strings.SplitN(pair, "=", 2)
+		parsed = append(parsed, EnvVar{Name: split[NAME], Value: split[VALUE]})
 	}
 
 	return parsed
 }
 
-func parseConfigRefs() (parsed []EnvVar) {
-	var temp string
-
-	if len(edgeConfigs) > 0 {
-		for i := range edgeConfigs {
-			temp = edgeConfigs[i]
-			split := strings.Split(temp, "=")
-			valueSplit := strings.Split(split[VALUE], ":")
-			parsed = append(parsed, EnvVar{Name: split[NAME], ValueFrom: &EnVarSource{ConfigRef{valueSplit[NAME], valueSplit[VALUE]}}})
-		}
-	} else {
-		return []EnvVar{}
+// configRefsFromPairs converts "NAME=config:key" pairs into env vars referencing Edge configuration
+func configRefsFromPairs(pairs []string) []EnvVar {
+	parsed := []EnvVar{}
+	for _, pair := range pairs {
+		split := strings.SplitN(pair, "=", 2)
+		valueSplit := strings.SplitN(split[VALUE], ":", 2)
+		parsed = append(parsed, EnvVar{Name: split[NAME], ValueFrom: &EnVarSource{EdgeConfigRef: ConfigRef{valueSplit[NAME], valueSplit[VALUE]}}})
 	}
 
 	return parsed
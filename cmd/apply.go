@@ -0,0 +1,408 @@
+// Copyright © 2016 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/30x/shipyardctl/utils"
+	"github.com/spf13/cobra"
+)
+
+var deploymentsFile string
+var prune bool
+
+const (
+	changeCreate    = "create"
+	changeUpdate    = "update"
+	changePrune     = "prune"
+	changeUnchanged = "unchanged"
+	changeUnlisted  = "unlisted"
+)
+
+// liveDeployment the state of an active deployment that can be declared in a deployments file
+type liveDeployment struct {
+	Name     string
+	Revision string
+	Replicas int // -1 when not reported
+	EnvVars  []EnvVar
+}
+
+// deploymentChange what it takes to bring one deployment to its declared state
+type deploymentChange struct {
+	Env     string // org:env
+	Name    string
+	Action  string
+	Spec    utils.DeploymentSpec
+	Live    *liveDeployment
+	Patch   deploymentPatch
+	Details []string
+}
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply -f {deployments.yaml} [--prune]",
+	Short: "brings deployments to the state declared in a file",
+	Long: `Given a file declaring the desired deployments of one or more environments,
+this compares them with the active deployments, prints a plan, then creates the
+missing deployments and updates those that drifted. With --prune, active deployments
+not listed in an environment of the file are undeployed, once confirmed or given --force.
+
+The file lists deployments per org:env. Replicas are left as they are when not given.
+
+environments:
+  acme:test:
+    - name: example
+      revision: 4
+      replicas: 2
+      envVars:
+        NODE_ENV: production
+      edgeConfigs:
+        API_KEY: secrets:apiKey
+
+Example of use:
+$ shipyardctl apply -f deployments.yaml
+$ shipyardctl apply -f deployments.yaml --prune
+$ shipyardctl apply -f deployments.yaml --prune --force`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := RequireAuthToken(); err != nil {
+			return err
+		}
+
+		return RequireDeploymentsFile()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		file, err := utils.LoadDeploymentsFile(deploymentsFile)
+		checkError(err, "")
+
+		changes, err := planDeployments(file)
+		checkError(err, "")
+
+		out := humanOutput()
+		if !printPlan(out, changes) {
+			return
+		}
+
+		pruned := 0
+		for _, change := range changes {
+			if change.Action == changePrune {
+				pruned++
+			}
+		}
+
+		if pruned > 0 && !force && !dryRun {
+			confirmed, err := PromptPruneDeployments(pruned)
+			checkError(err, "")
+
+			if !confirmed {
+				fmt.Fprintln(out, "Nothing was changed.")
+				return
+			}
+		}
+
+		failed := 0
+		for _, change := range changes {
+			var status int
+
+			switch change.Action {
+			case changeCreate:
+				fmt.Fprintf(out, "\nCreating %s in %s\n", change.Name, change.Env)
+				status = applyCreate(change)
+			case changeUpdate:
+				fmt.Fprintf(out, "\nUpdating %s in %s\n", change.Name, change.Env)
+				status = retryOnAuthn(func() int {
+					return updateDeploymentFrom(change.Env, change.Name, change.Patch, envDigests(change.Live.EnvVars))
+				})
+			case changePrune:
+				fmt.Fprintf(out, "\nUndeploying %s in %s\n", change.Name, change.Env)
+				status = retryOnAuthn(func() int {
+					return undeployApplicationFrom(change.Env, change.Name, envDigests(change.Live.EnvVars))
+				})
+			default:
+				continue
+			}
+
+			if !dryRun && (status < 200 || status >= 300) {
+				failed++
+			}
+		}
+
+		if failed > 0 {
+			fmt.Fprintf(os.Stderr, "\n%d change(s) failed.\n", failed)
+			os.Exit(1)
+		}
+	},
+}
+
+func applyCreate(change deploymentChange) int {
+	replicas32 := int32(defaultReplicas)
+	if change.Spec.Replicas != nil {
+		replicas32 = int32(*change.Spec.Replicas)
+	}
+
+	vars := specEnvVars(change.Spec)
+	return retryOnAuthn(func() int {
+		return deployApplication(change.Env, change.Name, int32(change.Spec.Revision), replicas32, vars)
+	})
+}
+
+// retryOnAuthn makes the call again after logging in, when its token was rejected
+func retryOnAuthn(call func() int) int {
+	status := call()
+	if !CheckIfAuthn(status) {
+		// retry once more
+		status = call()
+		if status == 401 {
+			fmt.Println("Unable to authenticate. Please check your SSO target URL is correct.")
+			fmt.Println("Command failed.")
+		}
+	}
+
+	return status
+}
+
+// planDeployments compares each environment of the file with its active deployments
+func planDeployments(file *utils.DeploymentsFile) ([]deploymentChange, error) {
+	var changes []deploymentChange
+
+	for _, env := range file.EnvironmentNames() {
+		live, status, err := getLiveDeployments(env)
+		if !CheckIfAuthn(status) {
+			// retry once more
+			live, status, err = getLiveDeployments(env)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		listed := map[string]bool{}
+		for _, spec := range file.Environments[env] {
+			listed[spec.Name] = true
+
+			var current *liveDeployment
+			if dep, ok := live[spec.Name]; ok {
+				current = &dep
+			}
+
+			changes = append(changes, planDeployment(env, spec, current))
+		}
+
+		var unlisted []string
+		for name := range live {
+			if !listed[name] {
+				unlisted = append(unlisted, name)
+			}
+		}
+
+		sort.Strings(unlisted)
+		for _, name := range unlisted {
+			dep := live[name]
+			change := deploymentChange{Env: env, Name: name, Action: changeUnlisted, Live: &dep}
+			if prune {
+				change.Action = changePrune
+			}
+
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+func planDeployment(env string, spec utils.DeploymentSpec, live *liveDeployment) deploymentChange {
+	change := deploymentChange{Env: env, Name: spec.Name, Spec: spec, Live: live}
+	desiredVars := specEnvVars(spec)
+
+	if live == nil {
+		change.Action = changeCreate
+		change.Details = append(change.Details, fmt.Sprintf("revision %d", spec.Revision))
+		if spec.Replicas != nil {
+			change.Details = append(change.Details, fmt.Sprintf("%d replicas", *spec.Replicas))
+		}
+
+		if diff := utils.DiffEnvDigests(nil, envDigests(desiredVars)); len(diff) > 0 {
+			change.Details = append(change.Details, "env vars "+strings.Join(diff, ", "))
+		}
+
+		return change
+	}
+
+	if revision := fmt.Sprint(spec.Revision); live.Revision != revision {
+		revision32 := int32(spec.Revision)
+		change.Patch.Revision = &revision32
+		change.Details = append(change.Details, fmt.Sprintf("revision %s -> %s", live.Revision, revision))
+	}
+
+	if spec.Replicas != nil && live.Replicas != *spec.Replicas {
+		replicas32 := int32(*spec.Replicas)
+		change.Patch.Replicas = &replicas32
+		change.Details = append(change.Details, fmt.Sprintf("replicas %d -> %d", live.Replicas, *spec.Replicas))
+	}
+
+	if diff := utils.DiffEnvDigests(envDigests(live.EnvVars), envDigests(desiredVars)); len(diff) > 0 {
+		change.Details = append(change.Details, "env vars "+strings.Join(diff, ", "))
+		if len(desiredVars) == 0 {
+			// an empty list is left out of the patch
+			change.Details = append(change.Details, "(removing every env var needs an undeploy and deploy)")
+		}
+
+		change.Patch.EnvVars = desiredVars
+	}
+
+	change.Action = changeUnchanged
+	if len(change.Details) > 0 {
+		change.Action = changeUpdate
+	}
+
+	return change
+}
+
+// printPlan describes the changes per environment, reporting whether any are to be made
+func printPlan(w io.Writer, changes []deploymentChange) bool {
+	symbols := map[string]string{
+		changeCreate:    "+",
+		changeUpdate:    "~",
+		changePrune:     "-",
+		changeUnchanged: "=",
+		changeUnlisted:  "?",
+	}
+
+	counts := map[string]int{}
+	env := ""
+
+	for _, change := range changes {
+		if change.Env != env {
+			env = change.Env
+			fmt.Fprintln(w, env)
+		}
+
+		counts[change.Action]++
+
+		description := change.Action
+		switch change.Action {
+		case changeUpdate:
+			description = strings.Join(change.Details, ", ")
+		case changeCreate:
+			description = "create with " + strings.Join(change.Details, ", ")
+		case changeUnlisted:
+			description = "not in the file, left as it is without --prune"
+		case changePrune:
+			description = "not in the file, undeploy"
+		}
+
+		fmt.Fprintf(w, "  %s %s: %s\n", symbols[change.Action], change.Name, description)
+	}
+
+	fmt.Fprintf(w, "Plan: %d to create, %d to update, %d to undeploy, %d unchanged.\n",
+		counts[changeCreate], counts[changeUpdate], counts[changePrune], counts[changeUnchanged])
+
+	return counts[changeCreate]+counts[changeUpdate]+counts[changePrune] > 0
+}
+
+// specEnvVars retrieves the env vars and edge config refs declared for the deployment
+func specEnvVars(spec utils.DeploymentSpec) []EnvVar {
+	return append(envVarsFromPairs(spec.EnvVarPairs()), configRefsFromPairs(spec.EdgeConfigPairs())...)
+}
+
+// getLiveDeployments retrieves the active deployments of the environment by name,
+// along with the response status
+func getLiveDeployments(shipyardEnv string) (map[string]liveDeployment, int, error) {
+	req, err := http.NewRequest("GET", clusterTarget+enroberPath+"/"+shipyardEnv+"/deployments", nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if debug {
+		PrintDebugRequest(req)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+authToken)
+	response, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if debug {
+		PrintDebugResponse(response)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		return nil, response.StatusCode, fmt.Errorf("There was a problem retrieving deployments in %s: %s", shipyardEnv, response.Status)
+	}
+
+	var list struct {
+		Items []map[string]interface{} `json:"items"`
+	}
+
+	if err = json.NewDecoder(response.Body).Decode(&list); err != nil {
+		return nil, response.StatusCode, err
+	}
+
+	live := map[string]liveDeployment{}
+	for _, item := range list.Items {
+		dep := parseLiveDeployment(item)
+		live[dep.Name] = dep
+	}
+
+	return live, response.StatusCode, nil
+}
+
+// parseLiveDeployment reads the revision, replicas and env vars of a deployment as
+// returned by Enrober. The env vars are those of its first container.
+func parseLiveDeployment(dep map[string]interface{}) liveDeployment {
+	metadata, _ := dep["metadata"].(map[string]interface{})
+	labels, _ := metadata["labels"].(map[string]interface{})
+	spec, _ := dep["spec"].(map[string]interface{})
+	template, _ := spec["template"].(map[string]interface{})
+	podSpec, _ := template["spec"].(map[string]interface{})
+	containers, _ := podSpec["containers"].([]interface{})
+
+	live := liveDeployment{
+		Name:     fmt.Sprint(metadata["name"]),
+		Revision: fmt.Sprint(templateParseRevision(labels)),
+		Replicas: numberOr(spec["replicas"], -1).(int),
+		EnvVars:  []EnvVar{},
+	}
+
+	if len(containers) > 0 {
+		container, _ := containers[0].(map[string]interface{})
+		if env, ok := container["env"]; ok {
+			// re-encode, as EnvVar decodes the field names case-insensitively
+			if data, err := json.Marshal(env); err == nil {
+				json.Unmarshal(data, &live.EnvVars)
+			}
+		}
+	}
+
+	return live
+}
+
+func init() {
+	RootCmd.AddCommand(applyCmd)
+
+	applyCmd.Flags().StringVarP(&deploymentsFile, "file", "f", "", "file declaring the desired deployments")
+	applyCmd.Flags().BoolVar(&prune, "prune", false, "undeploy active deployments not listed in an environment of the file")
+	applyCmd.Flags().BoolVar(&force, "force", false, "undeploy pruned deployments without asking for confirmation")
+	applyCmd.Flags().StringVar(&format, "format", "", "output format for responses: json, yaml, raw")
+}
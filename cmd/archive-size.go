@@ -0,0 +1,195 @@
+// Copyright © 2016 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ryanuber/columnize"
+)
+
+var ignoreSizeLimit bool
+
+// largeFileSize files over this size are called out when analyzing an archive
+const largeFileSize = 10 << 20
+
+// archiveWarnings contents of an archive that are usually a mistake
+var archiveWarnings = []struct {
+	pattern *regexp.Regexp
+	message string
+}{
+	{regexp.MustCompile(`(^|/)\.git/`), "under .git/, version control history isn't needed to run the app"},
+	{regexp.MustCompile(`(^|/)(coverage|\.nyc_output)/`), "of test coverage output"},
+	{regexp.MustCompile(`(^|/)node_modules/\.bin/`), "under node_modules/.bin/, links to dependency executables installed on this machine"},
+	{regexp.MustCompile(`\.node$`), "of compiled native addons, built for this machine rather than the Shipyard runtime"},
+}
+
+// sizedPath a file or directory of an archive and its uncompressed size
+type sizedPath struct {
+	Name string
+	Size int64
+}
+
+// archiveAnalysis the size of an application archive and what makes it up
+type archiveAnalysis struct {
+	Files        int
+	Compressed   int64 // size of the archive itself
+	Uncompressed int64
+	LargestFiles []sizedPath
+	LargestDirs  []sizedPath
+	Warnings     []string
+}
+
+// analyzeArchive measures the zip archive, finding its largest files and
+// directories and any contents that are likely a mistake. Dependencies installed
+// with --install are measured but not warned about.
+func analyzeArchive(zipPath string, installed bool) (*archiveAnalysis, error) {
+	info, err := os.Stat(zipPath)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	a := &archiveAnalysis{Compressed: info.Size()}
+	var files []sizedPath
+	dirs := map[string]int64{}
+	matches := make([]int, len(archiveWarnings))
+
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		size := int64(f.UncompressedSize64)
+		a.Files++
+		a.Uncompressed += size
+		files = append(files, sizedPath{f.Name, size})
+
+		for dir := path.Dir(f.Name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			dirs[dir+"/"] += size
+		}
+
+		if installed && strings.HasPrefix(f.Name, "node_modules/") {
+			continue
+		}
+
+		for i, w := range archiveWarnings {
+			if w.pattern.MatchString(f.Name) {
+				matches[i]++
+			}
+		}
+
+		if size > largeFileSize {
+			a.Warnings = append(a.Warnings, fmt.Sprintf("%s is %s, large files slow down every import", f.Name, formatBytes(size)))
+		}
+	}
+
+	for i, w := range archiveWarnings {
+		if matches[i] > 0 {
+			a.Warnings = append(a.Warnings, fmt.Sprintf("%d file(s) %s", matches[i], w.message))
+		}
+	}
+
+	for name, size := range dirs {
+		a.LargestDirs = append(a.LargestDirs, sizedPath{name, size})
+	}
+
+	a.LargestFiles = largestPaths(files, 10)
+	a.LargestDirs = largestPaths(a.LargestDirs, 5)
+
+	return a, nil
+}
+
+func largestPaths(paths []sizedPath, n int) []sizedPath {
+	sort.Sort(bySize(paths))
+	if len(paths) > n {
+		return paths[:n]
+	}
+
+	return paths
+}
+
+type bySize []sizedPath
+
+func (s bySize) Len() int      { return len(s) }
+func (s bySize) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s bySize) Less(i, j int) bool {
+	if s[i].Size != s[j].Size {
+		return s[i].Size > s[j].Size
+	}
+
+	return s[i].Name < s[j].Name
+}
+
+// printArchiveAnalysis outputs the archive size and warnings, along with the
+// largest files and directories when detailed
+func printArchiveAnalysis(w io.Writer, a *archiveAnalysis, detailed bool) {
+	if detailed {
+		if len(a.LargestFiles) > 0 {
+			lines := []string{"LARGEST FILES | SIZE"}
+			for _, f := range a.LargestFiles {
+				lines = append(lines, f.Name+" | "+formatBytes(f.Size))
+			}
+
+			fmt.Fprintln(w, columnize.SimpleFormat(lines))
+			fmt.Fprintln(w)
+		}
+
+		if len(a.LargestDirs) > 0 {
+			lines := []string{"LARGEST DIRECTORIES | SIZE"}
+			for _, d := range a.LargestDirs {
+				lines = append(lines, d.Name+" | "+formatBytes(d.Size))
+			}
+
+			fmt.Fprintln(w, columnize.SimpleFormat(lines))
+			fmt.Fprintln(w)
+		}
+	}
+
+	fmt.Fprintf(w, "Archive: %d files, %s compressed, %s uncompressed\n", a.Files, formatBytes(a.Compressed), formatBytes(a.Uncompressed))
+	for _, warning := range a.Warnings {
+		fmt.Fprintln(w, "WARNING", warning)
+	}
+}
+
+// checkArchiveSize refuses archives over the current context's max archive size
+func checkArchiveSize(a *archiveAnalysis) error {
+	if ignoreSizeLimit {
+		return nil
+	}
+
+	limit, err := config.GetCurrentMaxArchiveSize()
+	if err != nil {
+		return fmt.Errorf("Invalid maxarchivesize of context %s: %v", config.CurrentContext, err)
+	}
+
+	if limit > 0 && a.Compressed > limit {
+		return fmt.Errorf("The archive is %s, over the %s limit of context %s. Use --ignore-size-limit to import anyway.", formatBytes(a.Compressed), formatBytes(limit), config.CurrentContext)
+	}
+
+	return nil
+}
@@ -0,0 +1,242 @@
+// Copyright © 2016 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var archivePath string
+
+// isZipArchive reports whether the archive is a zip rather than a gzipped tarball
+func isZipArchive(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), ".zip")
+}
+
+// extractArchive writes the contents of a .zip, .tar.gz or .tgz archive under dir
+// and checks package.json sits at the archive root
+func extractArchive(path string, dir string) error {
+	var err error
+
+	lower := strings.ToLower(path)
+	switch {
+	case isZipArchive(path):
+		err = extractZip(path, dir)
+	case strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz"):
+		err = extractTarGz(path, dir)
+	default:
+		return fmt.Errorf("Unsupported archive %s, expected a .zip, .tar.gz or .tgz", path)
+	}
+
+	if err != nil {
+		return fmt.Errorf("Unable to read archive %s: %v", path, err)
+	}
+
+	return checkArchiveRoot(dir)
+}
+
+// checkArchiveRoot makes sure package.json is at the root of the extracted
+// archive, not nested under a base directory
+func checkArchiveRoot(dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, "package.json")); err == nil {
+		return nil
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 1 && entries[0].IsDir() {
+		if _, err := os.Stat(filepath.Join(dir, entries[0].Name(), "package.json")); err == nil {
+			return fmt.Errorf("package.json is nested under the base directory %q of the archive. It must be at the archive root", entries[0].Name())
+		}
+	}
+
+	return fmt.Errorf("No package.json found at the root of the archive")
+}
+
+func extractZip(path string, dir string) error {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	for _, f := range reader.File {
+		target, err := archiveTarget(dir, f.Name)
+		if err != nil {
+			return err
+		}
+
+		if f.FileInfo().IsDir() {
+			if err = os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if !f.Mode().IsRegular() {
+			continue // only files make it into the upload
+		}
+
+		content, err := f.Open()
+		if err != nil {
+			return err
+		}
+
+		err = writeFile(target, content, f.Mode().Perm())
+		content.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func extractTarGz(path string, dir string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	stream, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	return extractTar(stream, dir)
+}
+
+// archiveTarget resolves an archive entry name under dir, refusing names that escape
+// it and paths through symlinks placed by earlier entries
+func archiveTarget(dir string, name string) (string, error) {
+	target := filepath.Join(dir, filepath.FromSlash(name))
+	if !withinDir(dir, target) {
+		return "", fmt.Errorf("Archive entry %q is outside of the archive root", name)
+	}
+
+	current := dir
+	rel, _ := filepath.Rel(dir, target)
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		if part == "." {
+			continue
+		}
+
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			break
+		} else if err != nil {
+			return "", err
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("Archive entry %q would be written through a symlink", name)
+		}
+	}
+
+	return target, nil
+}
+
+// checkLinkTarget refuses symlink entries pointing outside of dir
+func checkLinkTarget(dir string, name string, target string, linkname string) error {
+	if filepath.IsAbs(linkname) {
+		return fmt.Errorf("Archive symlink %q has the absolute target %q", name, linkname)
+	}
+
+	if !withinDir(dir, filepath.Join(filepath.Dir(target), filepath.FromSlash(linkname))) {
+		return fmt.Errorf("Archive symlink %q points outside of the archive root", name)
+	}
+
+	return nil
+}
+
+// withinDir reports whether the cleaned path is dir or below it
+func withinDir(dir string, path string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// extractTar writes the files, directories and symlinks of a tar stream under dir
+func extractTar(stream io.Reader, dir string) error {
+	reader := tar.NewReader(stream)
+
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		target, err := archiveTarget(dir, header.Name)
+		if err != nil {
+			return err
+		}
+
+		mode := os.FileMode(header.Mode).Perm()
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0755)
+		case tar.TypeReg, tar.TypeRegA:
+			err = writeFile(target, reader, mode)
+		case tar.TypeSymlink:
+			if err = checkLinkTarget(dir, header.Name, target, header.Linkname); err != nil {
+				return err
+			}
+
+			if err = os.MkdirAll(filepath.Dir(target), 0755); err == nil {
+				err = os.Symlink(header.Linkname, target)
+			}
+		default:
+			continue // pax headers and other entries carry no files
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func writeFile(path string, content io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(file, content)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// archiveEntry a file, directory (name ending in /) or symlink (with a link) to archive
+type archiveEntry struct {
+	name string
+	body string
+	link string
+}
+
+func writeTestTarGz(t *testing.T, path string, entries []archiveEntry) {
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	stream := gzip.NewWriter(file)
+	writer := tar.NewWriter(stream)
+
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(e.body))}
+		switch {
+		case e.link != "":
+			header.Typeflag, header.Linkname, header.Size = tar.TypeSymlink, e.link, 0
+		case strings.HasSuffix(e.name, "/"):
+			header.Typeflag, header.Mode = tar.TypeDir, 0755
+		}
+
+		if err = writer.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = writer.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTestZip(t *testing.T, path string, entries []archiveEntry) {
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+
+	for _, e := range entries {
+		header := &zip.FileHeader{Name: e.name}
+		header.SetMode(0644)
+
+		body := e.body
+		switch {
+		case e.link != "":
+			header.SetMode(os.ModeSymlink | 0777)
+			body = e.link
+		case strings.HasSuffix(e.name, "/"):
+			header.SetMode(os.ModeDir | 0755)
+		}
+
+		w, err := writer.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractArchive(t *testing.T) {
+	pkg := archiveEntry{name: "package.json", body: "{}"}
+
+	cases := []struct {
+		name    string
+		entries []archiveEntry
+		err     string            // expected error, if any
+		files   map[string]string // expected content of the extracted files
+	}{
+		{
+			name:    "plain",
+			entries: []archiveEntry{pkg, {name: "lib/"}, {name: "lib/a.js", body: "a"}},
+			files:   map[string]string{"package.json": "{}", "lib/a.js": "a"},
+		},
+		{
+			name:    "parent directory",
+			entries: []archiveEntry{pkg, {name: "../x", body: "x"}},
+			err:     `Archive entry "../x" is outside of the archive root`,
+		},
+		{
+			name:    "parent directory within a path",
+			entries: []archiveEntry{pkg, {name: "lib/../../x", body: "x"}},
+			err:     `Archive entry "lib/../../x" is outside of the archive root`,
+		},
+		{
+			name:    "absolute path",
+			entries: []archiveEntry{pkg, {name: "/x", body: "x"}},
+			files:   map[string]string{"package.json": "{}", "x": "x"},
+		},
+		{
+			name:    "nested base directory",
+			entries: []archiveEntry{{name: "app/"}, {name: "app/package.json", body: "{}"}},
+			err:     `package.json is nested under the base directory "app" of the archive. It must be at the archive root`,
+		},
+		{
+			name:    "no package.json",
+			entries: []archiveEntry{{name: "index.js", body: ""}},
+			err:     "No package.json found at the root of the archive",
+		},
+	}
+
+	tarCases := []struct {
+		name    string
+		entries []archiveEntry
+		err     string
+		files   map[string]string
+	}{
+		{
+			name:    "symlink within the root",
+			entries: []archiveEntry{pkg, {name: "lib/a.js", body: "a"}, {name: "main.js", link: "lib/a.js"}},
+			files:   map[string]string{"package.json": "{}", "lib/a.js": "a", "main.js": "a"},
+		},
+		{
+			name:    "symlink outside the root",
+			entries: []archiveEntry{pkg, {name: "out", link: "../outside"}, {name: "out/x", body: "x"}},
+			err:     `Archive symlink "out" points outside of the archive root`,
+		},
+		{
+			name:    "absolute symlink",
+			entries: []archiveEntry{pkg, {name: "out", link: "/tmp"}, {name: "out/x", body: "x"}},
+			err:     `Archive symlink "out" has the absolute target "/tmp"`,
+		},
+		{
+			name:    "file through a symlink",
+			entries: []archiveEntry{pkg, {name: "lib/"}, {name: "link", link: "lib"}, {name: "link/x", body: "x"}},
+			err:     `Archive entry "link/x" would be written through a symlink`,
+		},
+	}
+
+	for _, format := range []string{"zip", "tar.gz"} {
+		run := append(cases, tarCases...)
+		if format == "zip" {
+			run = cases // symlinks in zips are skipped rather than extracted
+		}
+
+		for _, c := range run {
+			dir := tempDir(t)
+			defer os.RemoveAll(dir)
+
+			archive := filepath.Join(dir, "app."+format)
+			if format == "zip" {
+				writeTestZip(t, archive, c.entries)
+			} else {
+				writeTestTarGz(t, archive, c.entries)
+			}
+
+			root := filepath.Join(dir, "root")
+			if err := os.Mkdir(root, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			err := extractArchive(archive, root)
+			if c.err != "" {
+				if err == nil || !strings.HasSuffix(err.Error(), c.err) {
+					t.Errorf("%s %s: got error %v, want %q", format, c.name, err, c.err)
+				}
+			} else if err != nil {
+				t.Errorf("%s %s: %v", format, c.name, err)
+			}
+
+			for name, want := range c.files {
+				got, err := ioutil.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+				if err != nil || string(got) != want {
+					t.Errorf("%s %s: %s is %q (%v), want %q", format, c.name, name, got, err, want)
+				}
+			}
+
+			// nothing may land beside the extraction root
+			if entries, _ := ioutil.ReadDir(dir); len(entries) != 2 {
+				t.Errorf("%s %s: wrote %d entries outside of the root", format, c.name, len(entries)-2)
+			}
+		}
+	}
+}
+
+func TestExtractZipSkipsSymlinks(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	archive := filepath.Join(dir, "app.zip")
+	writeTestZip(t, archive, []archiveEntry{
+		{name: "package.json", body: "{}"},
+		{name: "out", link: "../outside"},
+		{name: "out/x", body: "x"},
+	})
+
+	root := filepath.Join(dir, "root")
+	if err := extractArchive(archive, root); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Lstat(filepath.Join(root, "out"))
+	if err != nil || !info.IsDir() {
+		t.Errorf("out should be extracted as a plain directory, got %v %v", info, err)
+	}
+
+	if _, err = os.Stat(filepath.Join(dir, "outside")); !os.IsNotExist(err) {
+		t.Errorf("the symlink target outside the root should not exist, got %v", err)
+	}
+}
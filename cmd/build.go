@@ -0,0 +1,167 @@
+// Copyright © 2016 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// BuildPhase a step of the image build, as reported in the build stream
+type BuildPhase struct {
+	Name     string  `json:"name" yaml:"name"`
+	Lines    int     `json:"lines" yaml:"lines"`
+	Duration float64 `json:"durationSeconds" yaml:"durationSeconds"`
+}
+
+// BuildResult the outcome of an application import build
+type BuildResult struct {
+	Succeeded    bool              `json:"succeeded" yaml:"succeeded"`
+	Reused       bool              `json:"reused,omitempty" yaml:"reused,omitempty"`
+	Organization string            `json:"organization,omitempty" yaml:"organization,omitempty"`
+	Application  string            `json:"application,omitempty" yaml:"application,omitempty"`
+	Revision     string            `json:"revision,omitempty" yaml:"revision,omitempty"`
+	Duration     float64           `json:"durationSeconds" yaml:"durationSeconds"`
+	Phases       []BuildPhase      `json:"phases" yaml:"phases"`
+	Metadata     map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	Error        string            `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+var outputRevisionFile string
+var skipUnchanged bool
+
+var buildResultRegex = regexp.MustCompile(`Organization: (\S+) \| Application: (\S+) \| Revision: (\S+)`)
+
+// phases of the docker build start with lines like "Step 2 : RUN npm install"
+var buildPhaseRegex = regexp.MustCompile(`^Step \d+(/\d+)? : (.*)$`)
+
+// handleBuildStream reads the build stream to its end, optionally echoing it to
+// out, and parses it into build phases and the final build result
+func handleBuildStream(stream io.Reader, out io.Writer) (*BuildResult, error) {
+	var line string
+	var data bytes.Buffer
+
+	start := time.Now()
+	result := &BuildResult{Phases: []BuildPhase{}}
+	phase := BuildPhase{Name: "prepare"}
+	phaseStart := start
+
+	endPhase := func() {
+		if phase.Lines > 0 {
+			phase.Duration = time.Since(phaseStart).Seconds()
+			result.Phases = append(result.Phases, phase)
+		}
+	}
+
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		line = scanner.Text()
+		data.WriteString(line + "\n")
+
+		if out != nil {
+			fmt.Fprintln(out, line)
+		}
+
+		if match := buildPhaseRegex.FindStringSubmatch(line); match != nil {
+			endPhase()
+			phase = BuildPhase{Name: match[2]}
+			phaseStart = time.Now()
+		}
+
+		phase.Lines++
+	}
+	endPhase()
+
+	result.Duration = time.Since(start).Seconds()
+
+	if err := scanner.Err(); err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
+
+	match := buildResultRegex.FindStringSubmatch(line)
+	if match == nil {
+		if out == nil {
+			result.Error = fmt.Sprintf("There was a problem during the build. Build output:\n%s\nPlease refer to the above build output", data.String())
+		} else {
+			result.Error = "There was a problem during the build. Refer to the build stream"
+		}
+
+		return result, errors.New(result.Error)
+	}
+
+	result.Succeeded = true
+	result.Organization = match[1]
+	result.Application = match[2]
+	result.Revision = match[3]
+
+	return result, nil
+}
+
+// formatBuildResult renders the build result as json or yaml, or the
+// human readable result line when no format is given
+func formatBuildResult(result *BuildResult, format string) ([]byte, error) {
+	switch format {
+	case "json":
+		return json.MarshalIndent(result, "", "  ")
+	case "yaml":
+		return yaml.Marshal(result)
+	case "":
+		return []byte(fmt.Sprintf("Organization: %s | Application: %s | Revision: %s", result.Organization, result.Application, result.Revision)), nil
+	default:
+		return nil, fmt.Errorf("Unsupported output format: %s", format)
+	}
+}
+
+// printBuildResult outputs the build result in the requested format and
+// saves the revision when asked to
+func printBuildResult(w io.Writer, result *BuildResult) error {
+	out, err := formatBuildResult(result, format)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, string(out))
+
+	if result.Succeeded && outputRevisionFile != "" {
+		return writeRevisionFile(outputRevisionFile, result)
+	}
+
+	return nil
+}
+
+// writeRevisionFile saves the built revision for later deploy steps
+func writeRevisionFile(path string, result *BuildResult) error {
+	return ioutil.WriteFile(path, []byte(result.Revision+"\n"), 0644)
+}
+
+// humanOutput where progress messages go: stderr when stdout holds machine readable output
+func humanOutput() io.Writer {
+	if format == "json" || format == "yaml" {
+		return os.Stderr
+	}
+
+	return os.Stdout
+}
@@ -0,0 +1,418 @@
+// Copyright © 2016 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/30x/shipyardctl/utils"
+	"github.com/spf13/cobra"
+)
+
+// buildPollInterval how long to wait before reattaching to a running build
+var buildPollInterval = 5 * time.Second
+
+var detach bool
+var buildID string
+var follow bool
+
+// buildStatus the state of an import build, as reported by the build service
+type buildStatus struct {
+	ID          string `json:"id"`
+	Application string `json:"application,omitempty"`
+	Status      string `json:"status"`
+	Revision    string `json:"revision,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Running reports whether the build has yet to finish
+func (b *buildStatus) Running() bool {
+	return b.Status == "" || b.Status == "pending" || b.Status == "running"
+}
+
+// buildsPath build service path of the organization's builds, appended to clusterTarget
+func buildsPath() string {
+	return fmt.Sprintf("/organizations/%s/builds", orgName)
+}
+
+// logsRootCmd represents the logs command
+var logsRootCmd = &cobra.Command{
+	Use:   "logs [command]",
+	Short: "retrieves the output of a Shipyard artifact",
+	Long: `This command, when paired with the proper subcommand, will retrieve the
+output of the respective artifact. An example call would look like:
+
+$ shipyardctl logs build -o acme --id 8a1f2c --follow`,
+}
+
+var getBuildCmd = &cobra.Command{
+	Use:   "build -o {org} --id {build id}",
+	Short: "retrieves the status of an application import build",
+	Long: `This retrieves the status of a build started with 'shipyardctl import application --detach'.
+
+Example of use:
+
+$ shipyardctl get build -o acme --id 8a1f2c`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := RequireAuthToken(); err != nil {
+			return err
+		}
+
+		if err := RequireOrgName(); err != nil {
+			return err
+		}
+
+		if err := RequireBuildID(); err != nil {
+			return err
+		}
+
+		if format == "" {
+			format = "get-build"
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		status := getBuild(buildID)
+		if !CheckIfAuthn(status) {
+			// retry once more
+			status := getBuild(buildID)
+			if status == 401 {
+				fmt.Println("Unable to authenticate. Please check your SSO target URL is correct.")
+				fmt.Println("Command failed.")
+			}
+		}
+	},
+}
+
+func getBuild(id string) int {
+	req, err := http.NewRequest("GET", clusterTarget+buildsPath()+"/"+url.QueryEscape(id), nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if debug {
+		PrintDebugRequest(req)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+authToken)
+	response, err := client.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if debug {
+		PrintDebugResponse(response)
+	}
+
+	defer response.Body.Close()
+
+	failure := fmt.Sprintf("\nThere was an error retrieving build %s.", id)
+	outputBasedOnStatus("", failure, response.Body, response.StatusCode, format)
+
+	return response.StatusCode
+}
+
+// getBuildStatus retrieves the state of the build, along with the response status
+func getBuildStatus(id string) (*buildStatus, int, error) {
+	req, err := http.NewRequest("GET", clusterTarget+buildsPath()+"/"+url.QueryEscape(id), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if debug {
+		PrintDebugRequest(req)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+authToken)
+	response, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if debug {
+		PrintDebugResponse(response)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		return nil, response.StatusCode, fmt.Errorf("There was an error retrieving build %s: %s", id, response.Status)
+	}
+
+	build := &buildStatus{}
+	if err = json.NewDecoder(response.Body).Decode(build); err != nil {
+		return nil, response.StatusCode, err
+	}
+
+	return build, response.StatusCode, nil
+}
+
+var logsBuildCmd = &cobra.Command{
+	Use:   "build -o {org} --id {build id}",
+	Short: "retrieves the output of an application import build",
+	Long: `This retrieves the output of a build started with 'shipyardctl import application --detach'.
+
+With --follow, the output is streamed until the build finishes, reattaching where it
+left off whenever the connection drops. The build result is then printed as with
+'shipyardctl import application', and can be written as JSON or YAML with --format.
+
+Example of use:
+
+$ shipyardctl logs build -o acme --id 8a1f2c --follow --output-revision-file rev.txt`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := RequireAuthToken(); err != nil {
+			return err
+		}
+
+		if err := RequireOrgName(); err != nil {
+			return err
+		}
+
+		if err := RequireBuildID(); err != nil {
+			return err
+		}
+
+		if !follow && (format != "" || outputRevisionFile != "") {
+			return fmt.Errorf("'--format' and '--output-revision-file' require '--follow'.")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		status := getBuildLogs(buildID)
+		if !CheckIfAuthn(status) {
+			// retry once more
+			status := getBuildLogs(buildID)
+			if status == 401 {
+				fmt.Println("Unable to authenticate. Please check your SSO target URL is correct.")
+				fmt.Println("Command failed.")
+			}
+		}
+	},
+}
+
+func getBuildLogs(id string) int {
+	logs := &buildLogReader{id: id, follow: follow}
+
+	status, err := logs.open()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if status != 200 {
+		return status
+	}
+
+	if !follow {
+		defer logs.Close()
+		if _, err = io.Copy(os.Stdout, logs.body); err != nil {
+			log.Fatal(err)
+		}
+
+		return status
+	}
+
+	// save the output under the built application, when it can be found
+	var hist *historyLog
+	if build, _, err := getBuildStatus(id); err == nil && build.Application != "" {
+		hist = startHistoryLog(utils.HistoryBuilds, build.Application)
+	}
+
+	result, err := handleBuildStream(hist.Tee(logs), humanOutput())
+	hist.Finish("", result.Revision, err == nil)
+
+	if result != nil && (format != "" || err == nil) {
+		checkError(printBuildResult(os.Stdout, result), "")
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return status
+}
+
+// buildLogReader reads the output of a build, reconnecting from where it left
+// off until the build has finished when following
+type buildLogReader struct {
+	id     string
+	follow bool
+	offset int64
+	body   io.ReadCloser
+	final  bool // the build has finished, read what remains and stop
+	done   bool
+}
+
+func (r *buildLogReader) open() (int, error) {
+	query := url.Values{}
+	query.Set("offset", fmt.Sprint(r.offset))
+	if r.follow {
+		query.Set("follow", "true")
+	}
+
+	req, err := http.NewRequest("GET", clusterTarget+buildsPath()+"/"+url.QueryEscape(r.id)+"/logs?"+query.Encode(), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	if debug {
+		PrintDebugRequest(req)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+authToken)
+	response, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	if debug {
+		PrintDebugResponse(response)
+	}
+
+	if response.StatusCode != 200 {
+		defer response.Body.Close()
+
+		if response.StatusCode != 401 {
+			out, _ := formatOutput("raw", response.Body)
+			return response.StatusCode, fmt.Errorf("There was an error retrieving the output of build %s: %s\n%s", r.id, response.Status, out)
+		}
+
+		return response.StatusCode, nil
+	}
+
+	r.body = response.Body
+	return response.StatusCode, nil
+}
+
+func (r *buildLogReader) Read(p []byte) (int, error) {
+	for {
+		if r.body == nil {
+			if r.done {
+				return 0, io.EOF
+			}
+
+			if status, err := r.open(); err != nil {
+				return 0, err
+			} else if status != 200 {
+				return 0, fmt.Errorf("There was an error retrieving the output of build %s: %d", r.id, status)
+			}
+		}
+
+		n, err := r.body.Read(p)
+		r.offset += int64(n)
+
+		if err != nil {
+			r.Close()
+			r.done = r.final || !r.follow
+
+			if err != io.EOF && !r.follow {
+				return n, err
+			}
+
+			if !r.done {
+				if err = r.reattach(err); err != nil {
+					return n, err
+				}
+			}
+		}
+
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// reattach decides whether to reconnect to the build output after the stream ended
+func (r *buildLogReader) reattach(cause error) error {
+	build, _, err := getBuildStatus(r.id)
+	if err != nil {
+		return err
+	}
+
+	if !build.Running() {
+		r.final = true // pick up anything written since the connection dropped
+		return nil
+	}
+
+	if cause != io.EOF {
+		fmt.Fprintf(os.Stderr, "Lost the build output: %v\nReattaching to build %s\n", cause, r.id)
+	}
+
+	time.Sleep(buildPollInterval)
+	return nil
+}
+
+// Close closes the current connection to the build output, if any
+func (r *buildLogReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
+
+// printDetachedBuild outputs the build started by a detached import of the application
+func printDetachedBuild(w io.Writer, name string, build *buildStatus) error {
+	if format != "" {
+		out, err := formatBuildStatus(build, format)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(w, string(out))
+		return nil
+	}
+
+	fmt.Fprintf(w, "\nBuild %s started for application %s.\n", build.ID, name)
+	fmt.Fprintf(w, "Follow it with: shipyardctl logs build -o %s --id %s --follow\n", orgName, build.ID)
+	return nil
+}
+
+func formatBuildStatus(build *buildStatus, format string) ([]byte, error) {
+	js, err := json.Marshal(build)
+	if err != nil {
+		return nil, err
+	}
+
+	return formatOutput(format, ioutil.NopCloser(bytes.NewReader(js)))
+}
+
+func init() {
+	RootCmd.AddCommand(logsRootCmd)
+
+	getCmd.AddCommand(getBuildCmd)
+	getBuildCmd.Flags().StringVarP(&orgName, "org", "o", "", "Apigee org name")
+	getBuildCmd.Flags().StringVar(&buildID, "id", "", "build identifier returned by 'import application --detach'")
+	getBuildCmd.Flags().StringVar(&format, "format", "", "output format: json,yaml,raw")
+
+	logsRootCmd.AddCommand(logsBuildCmd)
+	logsBuildCmd.Flags().StringVarP(&orgName, "org", "o", "", "Apigee org name")
+	logsBuildCmd.Flags().StringVar(&buildID, "id", "", "build identifier returned by 'import application --detach'")
+	logsBuildCmd.Flags().BoolVarP(&follow, "follow", "f", false, "stream the output until the build finishes")
+	logsBuildCmd.Flags().StringVar(&format, "format", "", "output format for the build result with --follow: json,yaml")
+	logsBuildCmd.Flags().StringVar(&outputRevisionFile, "output-revision-file", "", "file to write the built revision to with --follow")
+}
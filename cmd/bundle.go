@@ -49,6 +49,10 @@ Example of use:
 
 $ shipyardctl create bundle -n exampleName`,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := ApplyManifestDefaults(cmd); err != nil {
+			return err
+		}
+
 		if err := RequireBundleName(); err != nil {
 			return err
 		}
@@ -91,7 +95,13 @@ and creates an appropriate Edge proxy.
 
 $ shipyardctl deploy proxy -o acme -e test -z /path/to/bundle/zip `,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if err := RequireAuthToken(); err != nil {
+		if !dryRun {
+			if err := RequireAuthToken(); err != nil {
+				return err
+			}
+		}
+
+		if err := ApplyManifestDefaults(cmd); err != nil {
 			return err
 		}
 
@@ -119,7 +129,13 @@ $ shipyardctl deploy proxy -o acme -e test -z /path/to/bundle/zip `,
 			checkError(err, "Problem building proxy bundle")
 		}
 
-		err = mgmt.UploadProxyBundle(config.GetCurrentMgmtAPITarget(), orgName, envName, config.GetCurrentToken(), bundlePath, appName, debug)
+		if dryRun {
+			url := mgmt.ProxyImportURL(config.GetCurrentMgmtAPITarget(), orgName, appName)
+			checkError(printDryRunUpload("POST", url, nil, bundlePath), "")
+			return
+		}
+
+		err = mgmt.UploadProxyBundle(client, config.GetCurrentMgmtAPITarget(), orgName, envName, config.GetCurrentToken(), bundlePath, appName, debug)
 		checkError(err, "")
 	},
 }
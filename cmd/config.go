@@ -2,8 +2,10 @@ package cmd
 
 import (
   "fmt"
+  "io/ioutil"
   "os"
   "log"
+  "net/url"
 
   "github.com/spf13/cobra"
   "github.com/30x/shipyardctl/utils"
@@ -12,6 +14,8 @@ import (
 var cluster string
 var sso string
 var mgmtAPI string
+var onConflict string
+var maxArchiveSize string
 
 var useContextCmd = &cobra.Command{
 	Use:   "use-context",
@@ -30,14 +34,15 @@ $ shipyardctl config use-context e2e`,
 
     contextName := args[0]
 
-    if config == nil { // no config file
-      fmt.Println("There is no config file present at:", utils.GetConfigPath())
-    } else { // switch the current context to give name
-      err := config.SetContext(contextName)
-      if err != nil {
-        fmt.Println(err)
-        os.Exit(-1)
-      }
+    if !configFileExists() {
+      return
+    }
+
+    // switch the current context to give name
+    err := config.SetContext(contextName)
+    if err != nil {
+      fmt.Println(err)
+      os.Exit(-1)
     }
 
     return
@@ -51,6 +56,10 @@ var newContextCmd = &cobra.Command{
 Cluster target information will default to cluster-target=https://shipyard.apigee.com
 and sso-target=https://login.apigee.com unless otherwise specified.
 
+Imports larger than --max-archive-size are refused in the context, unless
+--ignore-size-limit is given. The limit can be changed later with
+'shipyardctl config set-context'.
+
 Example of use:
 
 $ shipyardctl config new-context e2e`,
@@ -62,14 +71,11 @@ $ shipyardctl config new-context e2e`,
 
     contextName := args[0]
 
-    if config == nil { // no config file
-      fmt.Println("There is no config file present at:", utils.GetConfigPath())
-    } else { // switch the current context to give name
-      err := config.NewContext(contextName, sso, cluster, mgmtAPI)
-      if err != nil {
-        fmt.Println(err)
-        os.Exit(-1)
-      }
+    // saving the context writes out the config file if there is none yet
+    err := config.NewContext(contextName, sso, cluster, mgmtAPI, maxArchiveSize)
+    if err != nil {
+      fmt.Println(err)
+      os.Exit(-1)
     }
 
     fmt.Printf("New context %s added!\nPlease switch contexts and login.\n", contextName)
@@ -78,6 +84,48 @@ $ shipyardctl config new-context e2e`,
 	},
 }
 
+var setContextCmd = &cobra.Command{
+	Use:   "set-context <name>",
+	Short: "set-context",
+	Long: `Changes the settings of an existing configuration context.
+
+Imports larger than --max-archive-size are refused in the context, unless
+--ignore-size-limit is given. An empty size removes the limit.
+
+Example of use:
+
+$ shipyardctl config set-context e2e --max-archive-size=100MB
+
+$ shipyardctl config set-context e2e --max-archive-size=""`,
+	Run: func(cmd *cobra.Command, args []string) {
+    if len(args) < 1 {
+      fmt.Println("Missing required context name")
+      os.Exit(-1)
+    }
+
+    contextName := args[0]
+
+    if !cmd.Flags().Lookup("max-archive-size").Changed {
+      fmt.Println("Nothing to change, give --max-archive-size")
+      os.Exit(-1)
+    }
+
+    if !configFileExists() {
+      os.Exit(-1)
+    }
+
+    err := config.SetMaxArchiveSize(contextName, maxArchiveSize)
+    if err != nil {
+      fmt.Println(err)
+      os.Exit(-1)
+    }
+
+    fmt.Printf("Context %s updated!\n", contextName)
+
+    return
+	},
+}
+
 var viewConfigCmd = &cobra.Command{
 	Use:   "view",
 	Short: "view",
@@ -88,19 +136,127 @@ Example of use:
 $ shipyardctl config view`,
 	Run: func(cmd *cobra.Command, args []string) {
 
-    if config == nil { // no config file
-      fmt.Println("There is no config file present at:", utils.GetConfigPath())
-    } else { // dump config file to stdout
-      err := config.DumpConfig()
-      if err != nil {
-        log.Fatal(err)
-      }
+    if !configFileExists() {
+      return
+    }
+
+    // dump config file to stdout
+    err := config.DumpConfig()
+    if err != nil {
+      log.Fatal(err)
     }
 
     return
 	},
 }
 
+var exportContextCmd = &cobra.Command{
+	Use:   "export <context...>",
+	Short: "export",
+	Long: `Prints the named contexts as YAML, without any user credentials, so they can be
+shared with others and imported with 'shipyardctl config import'.
+
+Example of use:
+
+$ shipyardctl config export e2e prod > contexts.yaml`,
+	Run: func(cmd *cobra.Command, args []string) {
+    if len(args) < 1 {
+      fmt.Println("Missing required context name")
+      os.Exit(-1)
+    }
+
+    if !configFileExists() {
+      os.Exit(-1)
+    }
+
+    data, err := config.ExportContexts(args)
+    if err != nil {
+      fmt.Println(err)
+      os.Exit(-1)
+    }
+
+    fmt.Print(string(data))
+
+    return
+	},
+}
+
+var importContextCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "import",
+	Long: `Merges the contexts of a file made by 'shipyardctl config export' into the config file.
+The file may be given as a path or a file:// URL.
+
+Contexts with a name already present are handled with --on-conflict:
+  skip       keep the existing context (default)
+  rename     import the context under a new, numbered name
+  overwrite  replace the existing context, keeping its credentials
+
+Example of use:
+
+$ shipyardctl config import contexts.yaml --on-conflict rename`,
+	Run: func(cmd *cobra.Command, args []string) {
+    if len(args) < 1 {
+      fmt.Println("Missing required file")
+      os.Exit(-1)
+    }
+
+    path, err := localPath(args[0])
+    if err != nil {
+      fmt.Println(err)
+      os.Exit(-1)
+    }
+
+    data, err := ioutil.ReadFile(path)
+    if err != nil {
+      fmt.Println(err)
+      os.Exit(-1)
+    }
+
+    results, err := config.ImportContexts(data, onConflict)
+    if err != nil {
+      fmt.Println(err)
+      os.Exit(-1)
+    }
+
+    for _, result := range results {
+      fmt.Println(result)
+    }
+
+    return
+	},
+}
+
+// configFileExists checks for the config file itself, as the config always has
+// defaults, and says where it was expected when it isn't there
+func configFileExists() bool {
+  exists, err := utils.ConfigExists()
+  if err == nil && exists {
+    return true
+  }
+
+  fmt.Println("There is no config file present at:", utils.GetConfigPath())
+  return false
+}
+
+// localPath resolves a path or file:// URL to a local file path
+func localPath(location string) (string, error) {
+  u, err := url.Parse(location)
+  if err != nil || u.Scheme == "" {
+    return location, nil
+  }
+
+  if u.Scheme != "file" {
+    return "", fmt.Errorf("Only local files can be imported, got: %s", location)
+  }
+
+  if u.Host != "" && u.Host != "localhost" {
+    return "", fmt.Errorf("Only local files can be imported, got host: %s", u.Host)
+  }
+
+  return u.Path, nil
+}
+
 var ConfigCmd = &cobra.Command{
 	Use:   "config <sub-command>",
 	Short: "config based commands",
@@ -112,7 +268,13 @@ $ shipyardctl config use-context e2e
 
 $ shipyardctl config view
 
-$ shipyardctl config new-context prod --cluster-target=https://my.shipyard.com`,
+$ shipyardctl config new-context prod --cluster-target=https://my.shipyard.com
+
+$ shipyardctl config set-context prod --max-archive-size=50MB
+
+$ shipyardctl config export prod > prod.yaml
+
+$ shipyardctl config import prod.yaml`,
 }
 
 func init() {
@@ -122,5 +284,11 @@ func init() {
   newContextCmd.Flags().StringVarP(&cluster, "cluster-target", "c", "https://shipyard.apigee.com", "Indicates the URL of the target cluster")
   newContextCmd.Flags().StringVarP(&sso, "sso-target", "s", "https://login.apigee.com", "Indicates the URL of the SSO target")
   newContextCmd.Flags().StringVarP(&mgmtAPI, "mgmt-api", "m", utils.DefaultMgmtApi, "The proxy management API target")
+  newContextCmd.Flags().StringVar(&maxArchiveSize, "max-archive-size", "", "Largest application archive to import with this context, ex. 50MB")
+  ConfigCmd.AddCommand(setContextCmd)
+  setContextCmd.Flags().StringVar(&maxArchiveSize, "max-archive-size", "", "Largest application archive to import with this context, ex. 50MB, empty for no limit")
+  ConfigCmd.AddCommand(exportContextCmd)
+  ConfigCmd.AddCommand(importContextCmd)
+  importContextCmd.Flags().StringVar(&onConflict, "on-conflict", utils.ConflictSkip, "How to handle contexts that already exist: skip, rename, overwrite")
   RootCmd.AddCommand(ConfigCmd)
 }
\ No newline at end of file
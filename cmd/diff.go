@@ -0,0 +1,281 @@
+// Copyright © 2016 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/30x/shipyardctl/utils"
+	"github.com/ryanuber/columnize"
+	"github.com/spf13/cobra"
+)
+
+const maskedValue = "********"
+
+// diffTroubleStatus exit status of a comparison that couldn't be made, as with diff(1)
+const diffTroubleStatus = 2
+
+var showValues bool
+
+// fieldDiff one compared field of a deployment, with an empty side when it is absent there
+type fieldDiff struct {
+	Field    string
+	Live     string
+	Declared string
+	HasLive  bool
+	HasDecl  bool
+	Changed  bool // compared by value, as masked values look the same
+}
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff -f {deployments.yaml} [--prune] [--format unified|table]",
+	Short: "shows how active deployments differ from a deployments file",
+	Long: `Given a file declaring the desired deployments of one or more environments (see
+"shipyardctl apply --help"), this compares the revision, replicas and env vars of
+each with the active deployments and shows the differences. With --prune, active
+deployments not listed in an environment of the file are shown as removed.
+
+Env var values are masked unless --show-values is given, with a changed value
+shown as "******** (changed)". Edge config refs are shown as config:key.
+
+Like diff(1), the command exits with status 1 when there are differences and 2 when
+the file or the active deployments can't be read, so it can gate CI.
+
+Example of use:
+$ shipyardctl diff -f deployments.yaml
+$ shipyardctl diff -f deployments.yaml --format table
+$ shipyardctl diff -f deployments.yaml --show-values`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := RequireAuthToken(); err != nil {
+			return err
+		}
+
+		if format != "" && format != "unified" && format != "table" {
+			return fmt.Errorf("Unknown format %q, use unified or table.", format)
+		}
+
+		return RequireDeploymentsFile()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		file, err := utils.LoadDeploymentsFile(deploymentsFile)
+		checkDiffError(err)
+
+		changes, err := planDeployments(file)
+		checkDiffError(err)
+
+		var different []deploymentChange
+		for _, change := range changes {
+			if change.Action == changeCreate || change.Action == changeUpdate || change.Action == changePrune {
+				different = append(different, change)
+			}
+		}
+
+		if len(different) == 0 {
+			fmt.Println("No differences.")
+			return
+		}
+
+		if format == "table" {
+			printDiffTable(os.Stdout, different)
+		} else {
+			printUnifiedDiff(os.Stdout, different)
+		}
+
+		os.Exit(1)
+	},
+}
+
+// printUnifiedDiff shows each differing deployment with its live state as removed
+// lines and its declared state as added lines
+func printUnifiedDiff(w io.Writer, changes []deploymentChange) {
+	source := filepath.Base(deploymentsFile)
+
+	for _, change := range changes {
+		name := change.Env + "/" + change.Name
+		fmt.Fprintf(w, "--- %s (live)\n", diffSide(change.Live != nil, name))
+		fmt.Fprintf(w, "+++ %s (%s)\n", diffSide(change.Action != changePrune, name), source)
+
+		for _, f := range deploymentDiff(change) {
+			if !f.Changed {
+				fmt.Fprintf(w, " %s: %s\n", f.Field, f.Live)
+				continue
+			}
+
+			if f.HasLive {
+				fmt.Fprintf(w, "-%s: %s\n", f.Field, f.Live)
+			}
+
+			if f.HasDecl {
+				fmt.Fprintf(w, "+%s: %s\n", f.Field, f.Declared)
+			}
+		}
+	}
+}
+
+// checkDiffError exits with diffTroubleStatus on error, so it isn't taken for differences
+func checkDiffError(err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\n%v\n", err)
+		os.Exit(diffTroubleStatus)
+	}
+}
+
+func diffSide(exists bool, name string) string {
+	if !exists {
+		return "/dev/null"
+	}
+
+	return name
+}
+
+// printDiffTable lists only the differing fields
+func printDiffTable(w io.Writer, changes []deploymentChange) {
+	lines := []string{"ENVIRONMENT | DEPLOYMENT | FIELD | LIVE | DECLARED"}
+	for _, change := range changes {
+		for _, f := range deploymentDiff(change) {
+			if f.Changed {
+				lines = append(lines, fmt.Sprintf("%s | %s | %s | %s | %s",
+					change.Env, change.Name, f.Field, orNone(f.HasLive, f.Live), orNone(f.HasDecl, f.Declared)))
+			}
+		}
+	}
+
+	fmt.Fprintln(w, columnize.SimpleFormat(lines))
+}
+
+func orNone(present bool, value string) string {
+	if !present {
+		return "-"
+	}
+
+	return value
+}
+
+// deploymentDiff compares the revision, replicas and env vars of the live and
+// declared deployment. Replicas not declared are taken to be as they are.
+func deploymentDiff(change deploymentChange) []fieldDiff {
+	declared := change.Action != changePrune
+	var diffs []fieldDiff
+
+	revision := fieldDiff{Field: "revision", HasDecl: declared}
+	if declared {
+		revision.Declared = fmt.Sprint(change.Spec.Revision)
+	}
+
+	replicas := fieldDiff{Field: "replicas", HasDecl: declared && change.Spec.Replicas != nil}
+	if replicas.HasDecl {
+		replicas.Declared = fmt.Sprint(*change.Spec.Replicas)
+	}
+
+	var liveEnv, declaredEnv []EnvVar
+	if change.Live != nil {
+		revision.HasLive = true
+		revision.Live = change.Live.Revision
+
+		if change.Live.Replicas >= 0 {
+			replicas.HasLive = true
+			replicas.Live = fmt.Sprint(change.Live.Replicas)
+		}
+
+		if !replicas.HasDecl && declared {
+			replicas.HasDecl = replicas.HasLive
+			replicas.Declared = replicas.Live
+		}
+
+		liveEnv = change.Live.EnvVars
+	}
+
+	if declared {
+		declaredEnv = specEnvVars(change.Spec)
+	}
+
+	revision.Changed = revision.HasLive != revision.HasDecl || revision.Live != revision.Declared
+	diffs = append(diffs, revision)
+	if replicas.HasLive || replicas.HasDecl {
+		replicas.Changed = replicas.HasLive != replicas.HasDecl || replicas.Live != replicas.Declared
+		diffs = append(diffs, replicas)
+	}
+
+	liveVars, declaredVars := envVarDisplay(liveEnv), envVarDisplay(declaredEnv)
+	liveDigests, declaredDigests := envDigests(liveEnv), envDigests(declaredEnv)
+
+	var names []string
+	for name := range liveVars {
+		names = append(names, name)
+	}
+
+	for name := range declaredVars {
+		if _, ok := liveVars[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		f := fieldDiff{Field: "env " + name}
+		f.Live, f.HasLive = liveVars[name]
+		f.Declared, f.HasDecl = declaredVars[name]
+		f.Changed = f.HasLive != f.HasDecl || liveDigests[name] != declaredDigests[name]
+		if f.Changed && f.HasLive && f.HasDecl {
+			f.Declared = changedDisplay(f.Live, f.Declared)
+		}
+		diffs = append(diffs, f)
+	}
+
+	return diffs
+}
+
+// changedDisplay marks a changed value that is masked on both sides, so it isn't
+// taken for being the same
+func changedDisplay(old string, updated string) string {
+	if old == updated {
+		return updated + " (changed)"
+	}
+
+	return updated
+}
+
+// envVarDisplay maps each env var to how its value is shown, masking the values
+// unless given --show-values
+func envVarDisplay(vars []EnvVar) map[string]string {
+	display := map[string]string{}
+
+	for _, v := range vars {
+		switch {
+		case v.ValueFrom != nil:
+			display[v.Name] = v.ValueFrom.String()
+		case v.Value != "" && !showValues:
+			display[v.Name] = maskedValue
+		default:
+			display[v.Name] = v.Value
+		}
+	}
+
+	return display
+}
+
+func init() {
+	RootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVarP(&deploymentsFile, "file", "f", "", "file declaring the desired deployments")
+	diffCmd.Flags().BoolVar(&prune, "prune", false, "show active deployments not listed in an environment of the file as removed")
+	diffCmd.Flags().StringVar(&format, "format", "", "output format: unified (default) or table")
+	diffCmd.Flags().BoolVar(&showValues, "show-values", false, "show env var values instead of masking them")
+}
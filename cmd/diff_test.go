@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/30x/shipyardctl/utils"
+)
+
+func TestDiffMasksChangedValues(t *testing.T) {
+	defer func(file string) { deploymentsFile = file }(deploymentsFile)
+	deploymentsFile = "deployments.yaml"
+
+	replicas := 2
+	change := deploymentChange{
+		Env:    "acme:test",
+		Name:   "example",
+		Action: changeUpdate,
+		Spec: utils.DeploymentSpec{
+			Name:        "example",
+			Revision:    3,
+			Replicas:    &replicas,
+			EnvVars:     map[string]string{"SAME": "a", "CHANGED": "new", "ADDED": "x", "EMPTIED": ""},
+			EdgeConfigs: map[string]string{"API_KEY": "secrets:apiKey"},
+		},
+		Live: &liveDeployment{
+			Name:     "example",
+			Revision: "3",
+			Replicas: 2,
+			EnvVars: []EnvVar{
+				{Name: "SAME", Value: "a"},
+				{Name: "CHANGED", Value: "old"},
+				{Name: "REMOVED", Value: "y"},
+				{Name: "EMPTIED", Value: "z"},
+				{Name: "API_KEY", ValueFrom: &EnVarSource{EdgeConfigRef: ConfigRef{"secrets", "apiKey"}}},
+			},
+		},
+	}
+
+	cases := []struct {
+		show bool
+		want string
+	}{
+		{false, `--- acme:test/example (live)
++++ acme:test/example (deployments.yaml)
+ revision: 3
+ replicas: 2
++env ADDED: ********
+ env API_KEY: edgeConfigRef secrets:apiKey
+-env CHANGED: ********
++env CHANGED: ******** (changed)
+-env EMPTIED: ********
++env EMPTIED: 
+-env REMOVED: ********
+ env SAME: ********
+`},
+		{true, `--- acme:test/example (live)
++++ acme:test/example (deployments.yaml)
+ revision: 3
+ replicas: 2
++env ADDED: x
+ env API_KEY: edgeConfigRef secrets:apiKey
+-env CHANGED: old
++env CHANGED: new
+-env EMPTIED: z
++env EMPTIED: 
+-env REMOVED: y
+ env SAME: a
+`},
+	}
+
+	defer func(show bool) { showValues = show }(showValues)
+	for _, c := range cases {
+		showValues = c.show
+
+		var out bytes.Buffer
+		printUnifiedDiff(&out, []deploymentChange{change})
+		if out.String() != c.want {
+			t.Errorf("with --show-values=%v got:\n%s\nwant:\n%s", c.show, out.String(), c.want)
+		}
+	}
+
+	showValues = false
+	var out bytes.Buffer
+	printDiffTable(&out, []deploymentChange{change})
+	if !strings.Contains(out.String(), "CHANGED") || !strings.Contains(out.String(), "******** (changed)") {
+		t.Errorf("the table should mark the masked change, got:\n%s", out.String())
+	}
+}
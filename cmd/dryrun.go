@@ -0,0 +1,136 @@
+// Copyright © 2016 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// secretNameRegex names of request fields whose values are masked in dry runs
+var secretNameRegex = regexp.MustCompile(`(?i)secret|passw|token|key|credential|private|auth`)
+
+// dryRunStatus status of a mutating call skipped for --dry-run
+const dryRunStatus = 0
+
+// skipForDryRun prints the request --dry-run keeps from being sent, with env var
+// and secret values redacted, and reports whether it was skipped
+func skipForDryRun(req *http.Request, body []byte) bool {
+	if !dryRun {
+		return false
+	}
+
+	fmt.Printf("Dry run, not sending:\n%s %s\n", req.Method, req.URL)
+	if len(body) > 0 {
+		fmt.Println(redactJSON(body))
+	}
+
+	fmt.Println()
+	return true
+}
+
+// printDryRunUpload prints the upload --dry-run keeps from being sent: the form
+// fields, with env var values redacted, and the files in the archive
+func printDryRunUpload(method string, url string, fields []formField, archive string) error {
+	fmt.Printf("Dry run, not sending:\n%s %s\n", method, url)
+	for _, field := range fields {
+		value := field.Value
+		if field.Name == "envVar" {
+			value = redactPair(value)
+		}
+
+		fmt.Printf("  %s: %s\n", field.Name, value)
+	}
+
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	fmt.Printf("  file: %s\n", archive)
+	for _, f := range reader.File {
+		fmt.Printf("    %s (%d bytes)\n", f.Name, f.UncompressedSize64)
+	}
+
+	fmt.Println()
+	return nil
+}
+
+// redactJSON indents a JSON body, masking the values of env vars, and of fields
+// with secret looking names
+func redactJSON(body []byte) string {
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return string(body)
+	}
+
+	out, err := json.MarshalIndent(redactValue(data), "", "  ")
+	if err != nil {
+		return string(body)
+	}
+
+	return string(out)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		// env vars are sent as a name and value
+		_, envVar := value["Name"]
+		if _, ok := value["name"]; ok {
+			envVar = true
+		}
+
+		for k, field := range value {
+			lower := strings.ToLower(k)
+			switch {
+			case field == "":
+			case lower == "value" && envVar:
+				value[k] = maskedValue
+			case lower != "key" && lower != "name" && secretNameRegex.MatchString(k):
+				if _, ok := field.(string); ok {
+					value[k] = maskedValue
+				}
+			default:
+				value[k] = redactValue(field)
+			}
+		}
+
+		return value
+	case []interface{}:
+		for i := range value {
+			value[i] = redactValue(value[i])
+		}
+
+		return value
+	default:
+		return v
+	}
+}
+
+// redactPair masks the value of a "NAME=VAL" pair
+func redactPair(pair string) string {
+	split := strings.SplitN(pair, "=", 2)
+	if len(split) == 2 && split[VALUE] != "" {
+		return split[NAME] + "=" + maskedValue
+	}
+
+	return pair
+}
@@ -73,7 +73,7 @@ func getEnvironment(envName string) int {
 	}
 
 	req.Header.Set("Authorization", "Bearer "+authToken)
-	response, err := http.DefaultClient.Do(req)
+	response, err := client.Do(req)
 
 	if err != nil {
 		log.Fatal(err)
@@ -101,8 +101,10 @@ var syncEnvCmd = &cobra.Command{
 Example of use:
 $ shipyardctl sync environment -o acme -e test`,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if err := RequireAuthToken(); err != nil {
-			return err
+		if !dryRun {
+			if err := RequireAuthToken(); err != nil {
+				return err
+			}
 		}
 
 		if err := RequireEnvName(); err != nil {
@@ -131,13 +133,16 @@ $ shipyardctl sync environment -o acme -e test`,
 
 func syncEnv(envName string) int {
 	req, err := http.NewRequest("PATCH", clusterTarget+enroberPath+"/"+envName, nil)
+	if skipForDryRun(req, nil) {
+		return dryRunStatus
+	}
 
 	if debug {
 		PrintDebugRequest(req)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+authToken)
-	response, err := http.DefaultClient.Do(req)
+	response, err := client.Do(req)
 
 	if err != nil {
 		log.Fatal(err)
@@ -0,0 +1,353 @@
+// Copyright © 2016 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/30x/shipyardctl/utils"
+	"github.com/spf13/cobra"
+)
+
+// setCmd represents the set command
+var setCmd = &cobra.Command{
+	Use:   "set [command]",
+	Short: "sets values on a Shipyard artifact",
+	Long:  `This command, when paired with the proper subcommand, will set values on the respective artifact.`,
+}
+
+// unsetCmd represents the unset command
+var unsetCmd = &cobra.Command{
+	Use:   "unset [command]",
+	Short: "removes values from a Shipyard artifact",
+	Long:  `This command, when paired with the proper subcommand, will remove values from the respective artifact.`,
+}
+
+var getEnvVarsCmd = &cobra.Command{
+	Use:   "env-vars -o {org} -e {env} -n {name}",
+	Short: "lists the environment variables of an active deployment",
+	Long: `Given the name of an active deployment, this lists its environment variables,
+including those referencing Edge configuration. Values are masked unless --show-values
+is given.
+
+Example of use:
+$ shipyardctl get env-vars -o acme -e test -n example
+$ shipyardctl get env-vars -o acme -e test -n example --show-values`,
+	PreRunE: requireDeploymentFlags,
+	Run: func(cmd *cobra.Command, args []string) {
+		vars := currentEnvVars(orgName+":"+envName, appName)
+		if !showValues {
+			vars = maskEnvVars(vars)
+		}
+
+		if len(vars) == 0 && format == "" {
+			fmt.Println("No environment variables.")
+			return
+		}
+
+		if format == "" {
+			format = "get-env-vars"
+		}
+
+		js, err := json.Marshal(vars)
+		checkError(err, "")
+
+		out, err := formatOutput(format, ioutil.NopCloser(bytes.NewReader(js)))
+		checkError(err, "")
+
+		fmt.Println(string(out))
+	},
+}
+
+var setEnvVarsCmd = &cobra.Command{
+	Use:   "env-vars -o {org} -e {env} -n {name} KEY=VAL... [--edge-config NAME=config:key]",
+	Short: "adds or changes environment variables of an active deployment",
+	Long: `Given the name of an active deployment, this adds the given environment variables
+to it, or changes their values, leaving the others as they are. The changes are shown
+before the deployment is updated, with the values masked unless --show-values is given.
+Variables set from other sources, such as Kubernetes secrets, are kept as they are.
+
+Example of use:
+$ shipyardctl set env-vars -o acme -e test -n example LOG_LEVEL=debug TIMEOUT=30
+$ shipyardctl set env-vars -o acme -e test -n example LOG_LEVEL=debug --show-values
+
+#Reference a value kept in Edge configuration
+$ shipyardctl set env-vars -o acme -e test -n example --edge-config API_KEY=secrets:apiKey`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireDeploymentFlags(cmd, args); err != nil {
+			return err
+		}
+
+		if len(args) == 0 && len(edgeConfigs) == 0 {
+			return fmt.Errorf("Give at least one KEY=VAL or '--edge-config'.")
+		}
+
+		if err := validateEnvVars(args); err != nil {
+			return err
+		}
+
+		return validateEdgeConfigs(edgeConfigs)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		shipyardEnv := orgName + ":" + envName
+		before := currentEnvVars(shipyardEnv, appName)
+
+		changes := append(envVarsFromPairs(args), configRefsFromPairs(edgeConfigs)...)
+		after := mergeEnvVars(before, changes)
+
+		changeEnvVars(shipyardEnv, appName, before, after)
+	},
+}
+
+var unsetEnvVarsCmd = &cobra.Command{
+	Use:   "env-vars -o {org} -e {env} -n {name} KEY...",
+	Short: "removes environment variables from an active deployment",
+	Long: `Given the name of an active deployment, this removes the given environment
+variables from it, leaving the others as they are. The changes are shown before the
+deployment is updated, with the values masked unless --show-values is given.
+
+Example of use:
+$ shipyardctl unset env-vars -o acme -e test -n example LOG_LEVEL TIMEOUT`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := requireDeploymentFlags(cmd, args); err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			return fmt.Errorf("Give at least one environment variable name to remove.")
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		shipyardEnv := orgName + ":" + envName
+		before := currentEnvVars(shipyardEnv, appName)
+
+		remove := map[string]bool{}
+		for _, name := range args {
+			remove[name] = true
+		}
+
+		after := []EnvVar{}
+		for _, v := range before {
+			if remove[v.Name] {
+				delete(remove, v.Name)
+				continue
+			}
+
+			after = append(after, v)
+		}
+
+		for _, name := range sortedSet(remove) {
+			fmt.Fprintf(os.Stderr, "%s is not set on %s, skipping.\n", name, appName)
+		}
+
+		changeEnvVars(shipyardEnv, appName, before, after)
+	},
+}
+
+func requireDeploymentFlags(cmd *cobra.Command, args []string) error {
+	if err := RequireAuthToken(); err != nil {
+		return err
+	}
+
+	if err := RequireOrgName(); err != nil {
+		return err
+	}
+
+	if err := RequireEnvName(); err != nil {
+		return err
+	}
+
+	return RequireAppName()
+}
+
+// currentEnvVars retrieves the environment variables of the active deployment
+func currentEnvVars(shipyardEnv string, name string) []EnvVar {
+	dep, status, err := getDeploymentState(shipyardEnv, name)
+	if !CheckIfAuthn(status) {
+		// retry once more
+		dep, status, err = getDeploymentState(shipyardEnv, name)
+	}
+	checkError(err, "")
+
+	return parseLiveDeployment(dep).EnvVars
+}
+
+// mergeEnvVars replaces the variables of the same name, in place, and appends the new ones
+func mergeEnvVars(vars []EnvVar, changes []EnvVar) []EnvVar {
+	merged := append([]EnvVar{}, vars...)
+	index := map[string]int{}
+	for i, v := range merged {
+		index[v.Name] = i
+	}
+
+	for _, v := range changes {
+		if i, ok := index[v.Name]; ok {
+			merged[i] = v
+		} else {
+			index[v.Name] = len(merged)
+			merged = append(merged, v)
+		}
+	}
+
+	return merged
+}
+
+// changeEnvVars shows how the variables change, then updates the deployment with the full new list
+func changeEnvVars(shipyardEnv string, name string, before []EnvVar, after []EnvVar) {
+	diff := utils.DiffEnvDigests(envDigests(before), envDigests(after))
+	if len(diff) == 0 {
+		fmt.Println("No changes.")
+		return
+	}
+
+	printEnvVarChanges(humanOutput(), diff, before, after)
+
+	if len(after) == 0 {
+		// an empty list is left out of the patch
+		fmt.Fprintln(os.Stderr, "Removing every environment variable needs an undeploy and deploy.")
+		os.Exit(1)
+	}
+
+	status := retryOnAuthn(func() int {
+		return updateDeploymentFrom(shipyardEnv, name, deploymentPatch{EnvVars: after}, envDigests(before))
+	})
+
+	if status != dryRunStatus && (status < 200 || status >= 300) {
+		os.Exit(1)
+	}
+}
+
+// printEnvVarChanges lists the added (+), removed (-) and changed (~) variables, masking
+// their values unless given --show-values
+func printEnvVarChanges(w io.Writer, diff []string, before []EnvVar, after []EnvVar) {
+	old, updated := envVarDisplay(before), envVarDisplay(after)
+
+	for _, change := range diff {
+		name := change[1:]
+		switch change[0] {
+		case '+':
+			fmt.Fprintf(w, "+ %s=%s\n", name, updated[name])
+		case '-':
+			fmt.Fprintf(w, "- %s=%s\n", name, old[name])
+		default:
+			fmt.Fprintf(w, "~ %s=%s -> %s\n", name, old[name], changedDisplay(old[name], updated[name]))
+		}
+	}
+}
+
+// maskEnvVars copies the variables, masking their values
+func maskEnvVars(vars []EnvVar) []EnvVar {
+	masked := []EnvVar{}
+	for _, v := range vars {
+		if v.ValueFrom == nil && v.Value != "" {
+			v.Value = maskedValue
+		}
+
+		masked = append(masked, v)
+	}
+
+	return masked
+}
+
+// UnmarshalJSON keeps sources other than an edge config ref as they are, so
+// they can be sent back unchanged
+func (s *EnVarSource) UnmarshalJSON(data []byte) error {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	if ref, ok := fields["edgeConfigRef"]; ok && len(fields) == 1 {
+		return json.Unmarshal(ref, &s.EdgeConfigRef)
+	}
+
+	s.raw = append(json.RawMessage{}, data...)
+	return nil
+}
+
+// MarshalJSON writes other sources back as they were read
+func (s EnVarSource) MarshalJSON() ([]byte, error) {
+	if s.raw != nil {
+		return s.raw, nil
+	}
+
+	return json.Marshal(struct {
+		EdgeConfigRef ConfigRef `json:"edgeConfigRef"`
+	}{s.EdgeConfigRef})
+}
+
+// String shows an edge config ref as "edgeConfigRef config:key", and other
+// sources by their kind, ex. "valueFrom secretKeyRef"
+func (s *EnVarSource) String() string {
+	if s.raw == nil {
+		return "edgeConfigRef " + s.EdgeConfigRef.Name + ":" + s.EdgeConfigRef.Key
+	}
+
+	fields := map[string]json.RawMessage{}
+	json.Unmarshal(s.raw, &fields)
+
+	var kinds []string
+	for kind := range fields {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	return strings.TrimSpace("valueFrom " + strings.Join(kinds, " "))
+}
+
+func sortedSet(set map[string]bool) []string {
+	var keys []string
+	for k := range set {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+func init() {
+	getCmd.AddCommand(getEnvVarsCmd)
+	getEnvVarsCmd.Flags().StringVarP(&orgName, "org", "o", "", "Apigee organization name")
+	getEnvVarsCmd.Flags().StringVarP(&envName, "env", "e", "", "Apigee environment name")
+	getEnvVarsCmd.Flags().StringVarP(&appName, "name", "n", "", "name of the active deployment")
+	getEnvVarsCmd.Flags().BoolVar(&showValues, "show-values", false, "show the values of the variables instead of masking them")
+	getEnvVarsCmd.Flags().StringVar(&format, "format", "", "output format: json,yaml")
+
+	RootCmd.AddCommand(setCmd)
+	setCmd.AddCommand(setEnvVarsCmd)
+	setEnvVarsCmd.Flags().StringVarP(&orgName, "org", "o", "", "Apigee organization name")
+	setEnvVarsCmd.Flags().StringVarP(&envName, "env", "e", "", "Apigee environment name")
+	setEnvVarsCmd.Flags().StringVarP(&appName, "name", "n", "", "name of the active deployment")
+	setEnvVarsCmd.Flags().StringSliceVar(&edgeConfigs, "edge-config", []string{}, "Edge-based configuration value to expose, \"NAME=config:key\"")
+	setEnvVarsCmd.Flags().BoolVar(&showValues, "show-values", false, "show the values of the changed variables instead of masking them")
+	setEnvVarsCmd.Flags().StringVar(&format, "format", "", "output format for response: json, yaml, raw")
+
+	RootCmd.AddCommand(unsetCmd)
+	unsetCmd.AddCommand(unsetEnvVarsCmd)
+	unsetEnvVarsCmd.Flags().StringVarP(&orgName, "org", "o", "", "Apigee organization name")
+	unsetEnvVarsCmd.Flags().StringVarP(&envName, "env", "e", "", "Apigee environment name")
+	unsetEnvVarsCmd.Flags().StringVarP(&appName, "name", "n", "", "name of the active deployment")
+	unsetEnvVarsCmd.Flags().BoolVar(&showValues, "show-values", false, "show the values of the removed variables instead of masking them")
+	unsetEnvVarsCmd.Flags().StringVar(&format, "format", "", "output format for response: json, yaml, raw")
+}
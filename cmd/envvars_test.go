@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/30x/shipyardctl/utils"
+)
+
+// liveDeploymentJSON an active deployment with a plain, edge config and secret variable
+const liveDeploymentJSON = `{
+  "metadata": {"name": "example", "labels": {"edge/app.rev": "3"}},
+  "spec": {"replicas": 2, "template": {"spec": {"containers": [{"env": [
+    {"name": "LOG_LEVEL", "value": "info"},
+    {"name": "API_KEY", "valueFrom": {"edgeConfigRef": {"name": "secrets", "key": "apiKey"}}},
+    {"name": "DB_PASSWORD", "valueFrom": {"secretKeyRef": {"name": "db", "key": "password"}}}
+  ]}]}}}
+}`
+
+func liveEnvVars(t *testing.T) []EnvVar {
+	dep := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(liveDeploymentJSON), &dep); err != nil {
+		t.Fatal(err)
+	}
+
+	return parseLiveDeployment(dep).EnvVars
+}
+
+func TestMergeEnvVars(t *testing.T) {
+	ref := &EnVarSource{EdgeConfigRef: ConfigRef{"secrets", "token"}}
+
+	cases := []struct {
+		name    string
+		vars    []EnvVar
+		changes []EnvVar
+		want    []EnvVar
+	}{
+		{
+			name: "nothing to change",
+			vars: []EnvVar{{Name: "A", Value: "1"}},
+			want: []EnvVar{{Name: "A", Value: "1"}},
+		},
+		{
+			name:    "replaced in place",
+			vars:    []EnvVar{{Name: "A", Value: "1"}, {Name: "B", Value: "2"}},
+			changes: []EnvVar{{Name: "A", Value: "3"}},
+			want:    []EnvVar{{Name: "A", Value: "3"}, {Name: "B", Value: "2"}},
+		},
+		{
+			name:    "appended in order",
+			vars:    []EnvVar{{Name: "B", Value: "2"}},
+			changes: []EnvVar{{Name: "C", Value: "3"}, {Name: "A", Value: "1"}},
+			want:    []EnvVar{{Name: "B", Value: "2"}, {Name: "C", Value: "3"}, {Name: "A", Value: "1"}},
+		},
+		{
+			name:    "later changes win",
+			changes: []EnvVar{{Name: "A", Value: "1"}, {Name: "A", Value: "2"}},
+			want:    []EnvVar{{Name: "A", Value: "2"}},
+		},
+		{
+			name:    "value replaced by an edge config ref",
+			vars:    []EnvVar{{Name: "TOKEN", Value: "plain"}},
+			changes: []EnvVar{{Name: "TOKEN", ValueFrom: ref}},
+			want:    []EnvVar{{Name: "TOKEN", ValueFrom: ref}},
+		},
+		{
+			name:    "edge config ref replaced by a value",
+			vars:    []EnvVar{{Name: "TOKEN", ValueFrom: ref}},
+			changes: []EnvVar{{Name: "TOKEN", Value: "plain"}},
+			want:    []EnvVar{{Name: "TOKEN", Value: "plain"}},
+		},
+	}
+
+	for _, c := range cases {
+		before := append([]EnvVar(nil), c.vars...)
+		got := mergeEnvVars(c.vars, c.changes)
+
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %+v, want %+v", c.name, got, c.want)
+		}
+
+		if !reflect.DeepEqual(c.vars, before) {
+			t.Errorf("%s: the variables merged into were changed to %+v", c.name, c.vars)
+		}
+	}
+}
+
+func TestEnvVarsKeepOtherSources(t *testing.T) {
+	live := liveEnvVars(t)
+	after := mergeEnvVars(live, envVarsFromPairs([]string{"LOG_LEVEL=debug"}))
+
+	data, err := json.Marshal(deploymentPatch{EnvVars: after})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"envVars":[` +
+		`{"Name":"LOG_LEVEL","Value":"debug"},` +
+		`{"Name":"API_KEY","Value":"","valueFrom":{"edgeConfigRef":{"Name":"secrets","Key":"apiKey"}}},` +
+		`{"Name":"DB_PASSWORD","Value":"","valueFrom":{"secretKeyRef":{"key":"password","name":"db"}}}]}`
+	if string(data) != want {
+		t.Errorf("patch\n%s\nwant\n%s", data, want)
+	}
+
+	// only the changed variable differs
+	diff := envDiff(live, after)
+	if !reflect.DeepEqual(diff, []string{"~LOG_LEVEL"}) {
+		t.Errorf("changes %v, want ~LOG_LEVEL", diff)
+	}
+
+	// a changed secret reference is still told apart
+	other := &EnVarSource{}
+	if err = json.Unmarshal([]byte(`{"secretKeyRef": {"name": "db", "key": "other"}}`), other); err != nil {
+		t.Fatal(err)
+	}
+
+	changed := mergeEnvVars(live, []EnvVar{{Name: "DB_PASSWORD", ValueFrom: other}})
+	if diff = envDiff(live, changed); !reflect.DeepEqual(diff, []string{"~DB_PASSWORD"}) {
+		t.Errorf("changes %v, want ~DB_PASSWORD", diff)
+	}
+}
+
+func TestPrintEnvVarChanges(t *testing.T) {
+	live := liveEnvVars(t)
+	after := mergeEnvVars(live, envVarsFromPairs([]string{"LOG_LEVEL=debug", "NEW=x"}))
+	after = mergeEnvVars(after, configRefsFromPairs([]string{"DB_PASSWORD=secrets:db"}))
+
+	cases := []struct {
+		show bool
+		want string
+	}{
+		{false, "~ DB_PASSWORD=valueFrom secretKeyRef -> edgeConfigRef secrets:db\n~ LOG_LEVEL=******** -> ******** (changed)\n+ NEW=********\n"},
+		{true, "~ DB_PASSWORD=valueFrom secretKeyRef -> edgeConfigRef secrets:db\n~ LOG_LEVEL=info -> debug\n+ NEW=x\n"},
+	}
+
+	defer func(show bool) { showValues = show }(showValues)
+	for _, c := range cases {
+		showValues = c.show
+
+		var out bytes.Buffer
+		printEnvVarChanges(&out, envDiff(live, after), live, after)
+		if out.String() != c.want {
+			t.Errorf("with --show-values=%v got:\n%s\nwant:\n%s", c.show, out.String(), c.want)
+		}
+	}
+}
+
+func TestGetEnvVarsTemplate(t *testing.T) {
+	js, err := json.Marshal(maskEnvVars(liveEnvVars(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := formatOutput("get-env-vars", ioutil.NopCloser(bytes.NewReader(js)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"LOG_LEVEL    ********", "API_KEY      edgeConfigRef secrets:apiKey", "DB_PASSWORD  valueFrom secretKeyRef"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("output should contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func envDiff(before []EnvVar, after []EnvVar) []string {
+	return utils.DiffEnvDigests(envDigests(before), envDigests(after))
+}
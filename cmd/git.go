@@ -0,0 +1,80 @@
+// Copyright © 2016 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"strings"
+)
+
+var gitRepo string
+var gitRef string
+
+// gitCommit resolves a sha, tag or branch of the repository to its full commit sha
+func gitCommit(repo string, ref string) (string, error) {
+	out, err := runGit(repo, "rev-parse", "--verify", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("Unable to resolve git ref %q in %s: %v", ref, repo, err)
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// gitArchive extracts the tree of the given commit into dir, exactly as
+// git archive would package it, regardless of the working copy
+func gitArchive(repo string, commit string, dir string) error {
+	cmd := exec.Command("git", "archive", "--format=tar", commit)
+	cmd.Dir = repo
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stream, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err = cmd.Start(); err != nil {
+		return err
+	}
+
+	extractErr := extractTar(stream, dir)
+	io.Copy(ioutil.Discard, stream) // let git finish writing if extraction stopped early
+
+	if err = cmd.Wait(); err != nil {
+		return fmt.Errorf("git archive failed: %v %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return extractErr
+}
+
+func runGit(repo string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repo
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil && stderr.Len() > 0 {
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return out, err
+}
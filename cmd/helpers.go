@@ -4,14 +4,16 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
-	"html/template"
 	"io"
 	"io/ioutil"
+	"math"
 	"os"
 	"strings"
+	"text/template"
 
 	"bytes"
 
+	"github.com/30x/shipyardctl/utils"
 	"github.com/ryanuber/columnize"
 	yaml "gopkg.in/yaml.v2"
 )
@@ -70,6 +72,80 @@ func RequireDirectory() error {
 	return nil
 }
 
+// RequireBuildID used to short circuit commands
+// requiring a build identifier, if it is not present
+func RequireBuildID() error {
+	if buildID == "" {
+		return fmt.Errorf("Missing required flag '--id'.")
+	}
+
+	return nil
+}
+
+// RequireDeploymentsFile used to short circuit commands
+// requiring a deployments file, if it is not present
+func RequireDeploymentsFile() error {
+	if deploymentsFile == "" {
+		return fmt.Errorf("Missing required flag '--file'.")
+	}
+
+	return nil
+}
+
+// RequireReplicas used to short circuit commands
+// given a replica count out of range
+func RequireReplicas(min int) error {
+	if replicas < min || replicas > math.MaxInt32 {
+		return fmt.Errorf("Invalid '--replicas' %d, must be at least %d.", replicas, min)
+	}
+
+	return nil
+}
+
+// LoadEnvFiles merges the variables from any --env-file under those given
+// with --env-var, and checks every variable is of the form "NAME=VAL"
+func LoadEnvFiles() error {
+	var fromFiles []string
+	for _, path := range envFiles {
+		pairs, err := utils.LoadDotenv(path)
+		if err != nil {
+			return fmt.Errorf("Unable to read env file: %v", err)
+		}
+
+		fromFiles = mergePairs(fromFiles, pairs)
+	}
+
+	envVars = mergePairs(fromFiles, envVars)
+	return validateEnvVars(envVars)
+}
+
+// validateEnvVars checks each env var is given as "NAME=VAL"
+func validateEnvVars(pairs []string) error {
+	for _, pair := range pairs {
+		if split := strings.SplitN(pair, "=", 2); len(split) < 2 || split[NAME] == "" {
+			return fmt.Errorf("Invalid env var %q, expected \"NAME=VAL\".", pair)
+		}
+	}
+
+	return nil
+}
+
+// validateEdgeConfigs checks each edge config ref is given as "NAME=config:key"
+func validateEdgeConfigs(pairs []string) error {
+	for _, pair := range pairs {
+		split := strings.SplitN(pair, "=", 2)
+		if len(split) < 2 || split[NAME] == "" {
+			return fmt.Errorf("Invalid edge config %q, expected \"NAME=config:key\".", pair)
+		}
+
+		if ref := strings.SplitN(split[VALUE], ":", 2); len(ref) < 2 || ref[NAME] == "" || ref[VALUE] == "" {
+			return fmt.Errorf("Invalid edge config %q, expected \"NAME=config:key\".", pair)
+		}
+	}
+
+	return nil
+}
+
 // RequireZipPath used to short circuit commands
 // requiring the path to a bundle zip, if it is not present
 func RequireZipPath() error {
@@ -104,6 +180,41 @@ func PromptAppDeletion(name string) (bool, error) {
 	return false, nil
 }
 
+// PromptPruneDeployments asks to confirm the undeploy of the deployments apply would prune
+func PromptPruneDeployments(count int) (bool, error) {
+	consolereader := bufio.NewReader(os.Stdin)
+	fmt.Fprintf(humanOutput(), "You are about to undeploy %d deployment(s) not listed in the file. Are you sure? [Y/n]: ", count)
+
+	input, err := consolereader.ReadString('\n')
+	if err != nil {
+		return false, err
+	}
+
+	input = strings.TrimSpace(input)
+
+	if input == "Y" {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// formatBytes renders a byte count in human readable units
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
 func templateParseDeploymentStatus(conditions []interface{}) bool {
 	for _, cond := range conditions {
 		if cond.(map[string]interface{})["reason"] == "MinimumReplicasAvailable" {
@@ -120,10 +231,20 @@ func templateParseRevision(labels map[string]interface{}) interface{} {
 	return labels["edge/app.rev"]
 }
 
+func templateJoin(values []interface{}) string {
+	var joined []string
+	for _, v := range values {
+		joined = append(joined, fmt.Sprint(v))
+	}
+
+	return strings.Join(joined, ", ")
+}
+
 func columnizeOutput(format string, data interface{}, temp string) ([]byte, error) {
 	funcMap := template.FuncMap{
 		"revision": templateParseRevision,
 		"status":   templateParseDeploymentStatus,
+		"join":     templateJoin,
 	}
 
 	tempGen, err := template.New("tempGen").Funcs(funcMap).Parse(temp)
@@ -178,6 +299,12 @@ func formatOutput(format string, body io.ReadCloser) ([]byte, error) {
 		return columnizeOutput(format, dat, GET_APP)
 	case "get-app-rev":
 		return columnizeOutput(format, dat, GET_APP_REV)
+	case "history-builds":
+		return columnizeOutput(format, dat, HISTORY_BUILDS)
+	case "history-deployments":
+		return columnizeOutput(format, dat, HISTORY_DEPLOYMENTS)
+	case "get-build":
+		return columnizeOutput(format, dat, GET_BUILD)
 	case "get-apps":
 		return columnizeOutput(format, dat, GET_APPS)
 	case "get-dep":
@@ -186,6 +313,10 @@ func formatOutput(format string, body io.ReadCloser) ([]byte, error) {
 		return columnizeOutput(format, dat, GET_DEPS)
 	case "get-env":
 		return columnizeOutput(format, dat, GET_ENV)
+	case "get-env-vars":
+		return columnizeOutput(format, dat, GET_ENV_VARS)
+	case "get-runtimes":
+		return columnizeOutput(format, dat, GET_RUNTIMES)
 	default:
 		return nil, nil
 	}
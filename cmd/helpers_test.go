@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEnvVarFlagKeepsCommas(t *testing.T) {
+	defer func() { envVars, envFiles = nil, nil }()
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	writeTree(t, dir, map[string]string{
+		"base.env":  "HOSTS=a,b\nLOG_LEVEL=info\n",
+		"extra.env": "LOG_LEVEL=warn\nTAGS=\"x, y\"\n",
+	})
+
+	for _, c := range []struct {
+		name string
+		args []string
+	}{
+		{"import", []string{"application"}},
+		{"deploy", []string{"application"}},
+	} {
+		envVars, envFiles = nil, nil
+
+		cmd, _, err := RootCmd.Find(append([]string{c.name}, c.args...))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = cmd.Flags().Parse([]string{
+			"--env-var", "ORIGINS=https://a.example,https://b.example",
+			"--env-var=LOG_LEVEL=debug",
+			"--env-file", filepath.Join(dir, "base.env"),
+			"--env-file", filepath.Join(dir, "extra.env"),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// reset the flags for the next command
+		cmd.Flags().Lookup("env-var").Changed = false
+		cmd.Flags().Lookup("env-file").Changed = false
+
+		if err = LoadEnvFiles(); err != nil {
+			t.Fatal(err)
+		}
+
+		want := []string{"HOSTS=a,b", "TAGS=x, y", "ORIGINS=https://a.example,https://b.example", "LOG_LEVEL=debug"}
+		if !reflect.DeepEqual(envVars, want) {
+			t.Errorf("%s: env vars %q, want %q", c.name, envVars, want)
+		}
+	}
+}
+
+func TestLoadEnvFilesInvalid(t *testing.T) {
+	defer func() { envVars, envFiles = nil, nil }()
+
+	for _, pairs := range [][]string{{"NOVALUE"}, {"=value"}} {
+		envVars, envFiles = pairs, nil
+		if err := LoadEnvFiles(); err == nil {
+			t.Errorf("%q should be refused", pairs)
+		}
+	}
+
+	envVars, envFiles = nil, []string{"does-not-exist.env"}
+	if err := LoadEnvFiles(); err == nil {
+		t.Error("a missing env file should be refused")
+	}
+}
@@ -0,0 +1,336 @@
+// Copyright © 2016 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/30x/shipyardctl/utils"
+	"github.com/spf13/cobra"
+)
+
+// historyLog saves a build stream or deploy response to the local history
+type historyLog struct {
+	entry *utils.HistoryEntry
+	file  *os.File
+}
+
+// startHistoryLog begins saving output for the application in the current org.
+// History is a convenience, so failures are only warned about.
+func startHistoryLog(kind string, app string) *historyLog {
+	entry, file, err := utils.CreateHistoryLog(kind, orgName, app)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Unable to save history:", err)
+		return nil
+	}
+
+	return &historyLog{entry, file}
+}
+
+// Tee copies everything read from r into the history log
+func (h *historyLog) Tee(r io.Reader) io.Reader {
+	if h == nil {
+		return r
+	}
+
+	return io.TeeReader(r, h.file)
+}
+
+// Write adds p to the history log
+func (h *historyLog) Write(p []byte) (int, error) {
+	if h == nil {
+		return len(p), nil
+	}
+
+	return h.file.Write(p)
+}
+
+// Finish records the outcome and indexes the entry, pruning old entries
+func (h *historyLog) Finish(env string, revision string, succeeded bool) {
+	if h == nil {
+		return
+	}
+
+	h.file.Close()
+	h.entry.Env = env
+	h.entry.Revision = revision
+	h.entry.Succeeded = succeeded
+
+	if err := utils.SaveHistoryEntry(h.entry, config.GetHistoryRetention()); err != nil {
+		fmt.Fprintln(os.Stderr, "Unable to save history:", err)
+	}
+}
+
+// recordDeployment saves a deployment change to the history, returning the
+// response body to be read again. before digests the live environment variables
+// ahead of the change, or is nil when they aren't known. vars is the full new
+// set of environment variables, or nil if an update left them as they are.
+func recordDeployment(shipyardEnv string, app string, revision string, before map[string]string, vars []EnvVar, request []byte, response *http.Response) io.ReadCloser {
+	data, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if response.StatusCode != 401 { // retried after logging in again
+		env := strings.SplitN(shipyardEnv, ":", 2)
+		envName := env[len(env)-1]
+		// env var values and secrets are only kept as digests, in the index
+		hist := startHistoryLog(utils.HistoryDeployments, app)
+		fmt.Fprintf(hist, "%s %s\n%s\n\n%s\n%s\n", response.Request.Method, response.Request.URL, redactJSON(request), response.Status, redactJSON(data))
+
+		if hist != nil {
+			hist.entry.Action = deploymentActions[response.Request.Method]
+			hist.entry.User = tokenUser()
+
+			after := before
+			if response.Request.Method == "DELETE" {
+				after = nil
+			} else if vars != nil || response.Request.Method == "POST" {
+				after = envDigests(vars)
+			}
+
+			if response.StatusCode < 300 {
+				// the changes can't be told without knowing what was there before
+				if before != nil {
+					hist.entry.EnvChanges = utils.DiffEnvDigests(before, after)
+				}
+				hist.entry.EnvVars = after
+			} else {
+				hist.entry.EnvVars = before
+			}
+		}
+
+		hist.Finish(envName, revision, response.StatusCode < 300)
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(data))
+}
+
+var deploymentActions = map[string]string{
+	"POST":   "deploy",
+	"PATCH":  "update",
+	"DELETE": "undeploy",
+}
+
+// envDigests maps each variable to a digest of its value, so changes can be
+// told apart without saving secrets in the history index
+func envDigests(vars []EnvVar) map[string]string {
+	digests := map[string]string{}
+	for _, v := range vars {
+		value := v.Value
+		if v.ValueFrom != nil && v.ValueFrom.raw != nil {
+			value = "valueFrom:" + string(v.ValueFrom.raw)
+		} else if v.ValueFrom != nil {
+			value = "edgeConfigRef:" + v.ValueFrom.EdgeConfigRef.Name + "/" + v.ValueFrom.EdgeConfigRef.Key
+		}
+
+		sum := sha256.Sum256([]byte(value))
+		digests[v.Name] = hex.EncodeToString(sum[:8])
+	}
+
+	return digests
+}
+
+// tokenUser identifies the user the auth token was issued to, falling back to
+// the username saved at login
+func tokenUser() string {
+	parts := strings.Split(authToken, ".")
+	if len(parts) == 3 {
+		claims := map[string]interface{}{}
+		payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+		if err == nil && json.Unmarshal(payload, &claims) == nil {
+			for _, claim := range []string{"user_name", "email", "sub"} {
+				if user, ok := claims[claim].(string); ok && user != "" {
+					return user
+				}
+			}
+		}
+	}
+
+	if config != nil && config.GetCurrentContext() != nil {
+		return config.GetCurrentUsername()
+	}
+
+	return ""
+}
+
+var historyID string
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history [command]",
+	Short: "shows the saved output of past builds and deployments",
+	Long: `The output of every application import build and deployment change is saved
+under the shipyardctl config directory, indexed by org, application and revision.
+
+By default the last 50 entries per application are kept, for up to 90 days. This
+can be changed in the config file:
+
+history:
+  maxEntries: 20
+  maxAgeDays: 30`,
+}
+
+var historyBuildsCmd = &cobra.Command{
+	Use:   "builds [-o {org}] [-n {name}[:{revision}]] [--id {id}]",
+	Short: "lists saved application import builds, or shows one",
+	Long: `This lists the saved application import builds, optionally of a single org or
+application. Given a revision or entry id, the saved build output is shown instead.
+
+Example of use:
+
+$ shipyardctl history builds -o acme -n echo-app1
+$ shipyardctl history builds -o acme -n echo-app1:4`,
+	Run: func(cmd *cobra.Command, args []string) {
+		nameSplit := strings.SplitN(appName, ":", 2)
+		entries, err := utils.ListHistory(utils.HistoryBuilds, orgName, nameSplit[NAME])
+		checkError(err, "Unable to read build history")
+
+		if historyID != "" || len(nameSplit) > 1 {
+			entry := findHistoryEntry(entries, historyID, nameSplit)
+			if entry == nil {
+				fmt.Println("No saved build matches.")
+				os.Exit(1)
+			}
+
+			data, err := utils.ReadHistoryLog(*entry)
+			checkError(err, "Unable to read saved build output")
+
+			fmt.Print(string(data))
+			return
+		}
+
+		printHistoryEntries(entries, "history-builds")
+	},
+}
+
+var historyDeploymentsCmd = &cobra.Command{
+	Use:     "deployments [-o {org}] [-e {env}] [-n {name}[:{revision}]] [--id {id}]",
+	Aliases: []string{"deployment"},
+	Short:   "lists saved deployment changes, or shows one",
+	Long: `This lists the timeline of deployments, updates and undeployments made with
+shipyardctl, optionally of a single org, environment or application. Each change
+records who made it, the revision and which environment variables were added (+),
+removed (-) or changed (~). Values are not listed.
+
+Given a revision or entry id, the saved request and response are shown instead.
+Use --format json to export the timeline.
+
+Example of use:
+
+$ shipyardctl history deployments -o acme -e test -n example
+$ shipyardctl history deployments -o acme -e test -n example --format json > example-history.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		nameSplit := strings.SplitN(appName, ":", 2)
+		entries, err := utils.ListHistory(utils.HistoryDeployments, orgName, nameSplit[NAME])
+		checkError(err, "Unable to read deployment history")
+
+		if envName != "" {
+			var inEnv []utils.HistoryEntry
+			for _, e := range entries {
+				if e.Env == envName {
+					inEnv = append(inEnv, e)
+				}
+			}
+
+			entries = inEnv
+		}
+
+		if historyID != "" || len(nameSplit) > 1 {
+			entry := findHistoryEntry(entries, historyID, nameSplit)
+			if entry == nil {
+				fmt.Println("No saved deployment change matches.")
+				os.Exit(1)
+			}
+
+			data, err := utils.ReadHistoryLog(*entry)
+			checkError(err, "Unable to read saved deployment change")
+
+			fmt.Print(string(data))
+			return
+		}
+
+		printHistoryEntries(entries, "history-deployments")
+	},
+}
+
+// findHistoryEntry retrieves the latest entry with the id, or the revision of the "name:revision" split
+func findHistoryEntry(entries []utils.HistoryEntry, id string, nameSplit []string) *utils.HistoryEntry {
+	for i := len(entries) - 1; i >= 0; i-- {
+		if id != "" && entries[i].ID != id {
+			continue
+		}
+
+		if len(nameSplit) > 1 && entries[i].Revision != nameSplit[VALUE] {
+			continue
+		}
+
+		return &entries[i]
+	}
+
+	return nil
+}
+
+// printHistoryEntries outputs the entries in the requested format, defaulting to the given template
+func printHistoryEntries(entries []utils.HistoryEntry, template string) {
+	if len(entries) == 0 && (format == "" || format == template) {
+		fmt.Println("No saved history.")
+		return
+	}
+
+	if format == "" {
+		format = template
+	}
+
+	if entries == nil {
+		entries = []utils.HistoryEntry{}
+	}
+
+	js, err := json.Marshal(entries)
+	checkError(err, "")
+
+	out, err := formatOutput(format, ioutil.NopCloser(bytes.NewReader(js)))
+	checkError(err, "")
+
+	fmt.Println(string(out))
+}
+
+func init() {
+	RootCmd.AddCommand(historyCmd)
+
+	historyCmd.AddCommand(historyBuildsCmd)
+	historyBuildsCmd.Flags().StringVarP(&orgName, "org", "o", "", "Apigee org name")
+	historyBuildsCmd.Flags().StringVarP(&appName, "name", "n", "", "application name and optional revision to show")
+	historyBuildsCmd.Flags().StringVar(&historyID, "id", "", "id of the entry to show")
+	historyBuildsCmd.Flags().StringVar(&format, "format", "", "output format: json,yaml")
+
+	historyCmd.AddCommand(historyDeploymentsCmd)
+	historyDeploymentsCmd.Flags().StringVarP(&orgName, "org", "o", "", "Apigee org name")
+	historyDeploymentsCmd.Flags().StringVarP(&envName, "env", "e", "", "Apigee environment name")
+	historyDeploymentsCmd.Flags().StringVarP(&appName, "name", "n", "", "application name and optional revision to show")
+	historyDeploymentsCmd.Flags().StringVar(&historyID, "id", "", "id of the entry to show")
+	historyDeploymentsCmd.Flags().StringVar(&format, "format", "", "output format: json,yaml")
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/30x/shipyardctl/utils"
+)
+
+func TestRecordDeploymentRedactsEnvVars(t *testing.T) {
+	// leave no trace of the test on a machine that hasn't used shipyardctl
+	if _, err := os.Stat(filepath.Dir(utils.GetConfigPath())); os.IsNotExist(err) {
+		defer os.RemoveAll(filepath.Dir(utils.GetConfigPath()))
+	}
+
+	dir, err := utils.ConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// keep to an org of our own within the user's history
+	defer func(org string) { orgName = org }(orgName)
+	orgName = fmt.Sprintf("shipyardctl-test-%d", os.Getpid())
+	defer os.RemoveAll(filepath.Join(dir, utils.HistoryDirName, utils.HistoryDeployments, orgName))
+
+	const secret = "s3cr3t-value"
+	vars := []EnvVar{
+		{Name: "DB_PASSWORD", Value: secret},
+		{Name: "API_KEY", ValueFrom: &EnVarSource{EdgeConfigRef: ConfigRef{"secrets", "apiKey"}}},
+	}
+
+	request := []byte(`{"deploymentName": "example", "revision": 3, "envVars": [{"name": "DB_PASSWORD", "value": "` + secret + `"}]}`)
+	body := `{"deploymentName": "example", "envVars": [{"name": "DB_PASSWORD", "value": "` + secret + `"}],` +
+		`"deployment": {"spec": {"template": {"spec": {"containers": [{"env": [{"name": "DB_PASSWORD", "value": "` + secret + `"}]}]}}}},` +
+		`"token": "` + secret + `"}`
+
+	req, err := http.NewRequest("POST", "https://shipyard.example/environments/acme:test/deployments", bytes.NewReader(request))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := &http.Response{StatusCode: 201, Status: "201 Created", Request: req, Body: ioutil.NopCloser(strings.NewReader(body))}
+	returned := recordDeployment("acme:test", "example", "3", map[string]string{}, vars, request, response)
+
+	// the caller still gets the response as it was sent
+	if data, _ := ioutil.ReadAll(returned); string(data) != body {
+		t.Errorf("returned body %s, want %s", data, body)
+	}
+
+	entries, err := utils.ListHistory(utils.HistoryDeployments, orgName, "example")
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("got history %v (%v), want one entry", entries, err)
+	}
+
+	if got := strings.Join(entries[0].EnvChanges, " "); got != "+API_KEY +DB_PASSWORD" {
+		t.Errorf("env changes %q, want +API_KEY +DB_PASSWORD", got)
+	}
+
+	log, err := utils.ReadHistoryLog(entries[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(log), "DB_PASSWORD") || !strings.Contains(string(log), maskedValue) {
+		t.Errorf("the log should show the masked variable, got:\n%s", log)
+	}
+
+	// nothing saved for the org, index included, may hold the value
+	err = filepath.Walk(filepath.Join(dir, utils.HistoryDirName, utils.HistoryDeployments, orgName), func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err == nil && bytes.Contains(data, []byte(secret)) {
+			t.Errorf("%s holds the env var value:\n%s", path, data)
+		}
+
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
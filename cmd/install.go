@@ -0,0 +1,138 @@
+// Copyright © 2016 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+
+	"github.com/30x/shipyardctl/utils"
+)
+
+// installAuto picks the install command to use from the lockfile of the app
+const installAuto = "auto"
+
+var installCommand string
+
+// resolveInstallCommand turns "auto" into the production install of the package
+// manager whose lockfile is present
+func resolveInstallCommand(command string, files []packageFile) string {
+	if command != installAuto {
+		return command
+	}
+
+	for _, f := range files {
+		if f.Name == "yarn.lock" {
+			return "yarn install --production --frozen-lockfile"
+		}
+	}
+
+	// npm honors package-lock.json and npm-shrinkwrap.json on its own
+	return "npm install --production"
+}
+
+// stageInstall copies the files into the staging directory and runs the install
+// command there, returning the staged files, including the installed dependencies.
+// An .npmrc in dir is made available to the install but not packaged, unless it is
+// one of the files. With --verbose, the command's output is written to out as it runs.
+func stageInstall(files []packageFile, dir string, command string, stage string, out io.Writer) ([]packageFile, error) {
+	for _, f := range files {
+		if err := copyPackageFile(f, filepath.Join(stage, filepath.FromSlash(f.Name))); err != nil {
+			return nil, err
+		}
+	}
+
+	// the install may need the registry credentials of an .npmrc left out of the package
+	npmrc := false
+	stagedNpmrc := filepath.Join(stage, ".npmrc")
+	if _, err := os.Stat(stagedNpmrc); os.IsNotExist(err) {
+		if info, err := os.Stat(filepath.Join(dir, ".npmrc")); err == nil {
+			f := packageFile{".npmrc", filepath.Join(dir, ".npmrc"), info.Size(), info.Mode()}
+			if err = copyPackageFile(f, stagedNpmrc); err != nil {
+				return nil, err
+			}
+
+			npmrc = true
+		}
+	}
+
+	var install *exec.Cmd
+	if goruntime.GOOS == "windows" {
+		install = exec.Command("cmd", "/C", command)
+	} else {
+		install = exec.Command("sh", "-c", command)
+	}
+
+	var output bytes.Buffer
+	install.Dir = stage
+	install.Env = append(os.Environ(), "NODE_ENV=production")
+	install.Stdout = &output
+	install.Stderr = &output
+
+	if verbose {
+		install.Stdout = out
+		install.Stderr = out
+	}
+
+	if err := install.Run(); err != nil {
+		return nil, fmt.Errorf("%q failed: %v\n%s", command, err, output.String())
+	}
+
+	if npmrc {
+		if err := os.Remove(stagedNpmrc); err != nil {
+			return nil, err
+		}
+	}
+
+	// the source was already filtered, but node_modules now belongs in the archive
+	var patterns []string
+	for _, p := range utils.DefaultIgnorePatterns {
+		if p != "node_modules/" && p != ".npmrc" {
+			patterns = append(patterns, p)
+		}
+	}
+
+	staged, _, err := walkPackageFiles(stage, utils.NewIgnoreMatcher(patterns))
+	return staged, err
+}
+
+func copyPackageFile(f packageFile, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	src, err := os.Open(f.Path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode.Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+
+	return dst.Close()
+}
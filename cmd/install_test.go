@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"testing"
+)
+
+func TestStageInstall(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("the install command is a shell script")
+	}
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	writeTree(t, src, map[string]string{
+		"package.json": "{}",
+		"index.js":     "",
+		".npmrc":       "//registry.example/:_authToken=secret",
+	})
+
+	files, _, err := listPackageFiles(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// fails unless the .npmrc is there during the install
+	command := "test -f .npmrc && mkdir -p node_modules/.bin node_modules/dep && " +
+		"touch node_modules/.bin/dep node_modules/dep/index.js node_modules/dep/addon.node"
+
+	staged, err := stageInstall(files, src, command, filepath.Join(dir, "stage"), ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := map[string]bool{}
+	for _, f := range staged {
+		names[f.Name] = true
+	}
+
+	for _, name := range []string{"package.json", "index.js", "node_modules/dep/index.js", "node_modules/.bin/dep"} {
+		if !names[name] {
+			t.Errorf("%s should be staged, got %v", name, names)
+		}
+	}
+
+	if names[".npmrc"] {
+		t.Error(".npmrc should not be staged")
+	}
+
+	if _, err = os.Stat(filepath.Join(src, "node_modules")); !os.IsNotExist(err) {
+		t.Errorf("the source directory should be left untouched, got %v", err)
+	}
+
+	zipPath := filepath.Join(dir, "app.zip")
+	if err = writePackage(staged, zipPath); err != nil {
+		t.Fatal(err)
+	}
+
+	analysis, err := analyzeArchive(zipPath, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(analysis.Warnings) != 0 || analysis.Files != len(staged) {
+		t.Errorf("installed dependencies should be measured but not warned about, got %d files, %q", analysis.Files, analysis.Warnings)
+	}
+
+	if analysis, err = analyzeArchive(zipPath, false); err != nil {
+		t.Fatal(err)
+	} else if len(analysis.Warnings) != 2 {
+		t.Errorf("packaged dependencies should be warned about, got %q", analysis.Warnings)
+	}
+}
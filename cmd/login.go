@@ -89,7 +89,7 @@ func Login() {
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded;charset=utf-8")
 	req.Header.Add("Accept", "application/json;charset=utf-8")
 
-	response, err := http.DefaultClient.Do(req)
+	response, err := client.Do(req)
 
 	if err != nil {
 		log.Fatal(err)
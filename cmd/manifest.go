@@ -0,0 +1,124 @@
+// Copyright © 2016 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/30x/shipyardctl/utils"
+	"github.com/spf13/cobra"
+)
+
+var manifestPath string
+var manifest *utils.Manifest
+
+// LoadManifest reads the project manifest from --manifest, or the first
+// shipyard.yaml found walking up from the working directory.
+// A nil manifest is returned when there is none.
+func LoadManifest() (*utils.Manifest, error) {
+	if manifest != nil {
+		return manifest, nil
+	}
+
+	path := manifestPath
+	if path == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		if path, err = utils.FindManifest(cwd); err != nil || path == "" {
+			return nil, err
+		}
+	}
+
+	m, err := utils.LoadManifest(path)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read project manifest %s: %v", path, err)
+	}
+
+	if debug {
+		fmt.Println("Using project manifest:", m.Path)
+	}
+
+	manifest = m
+	return manifest, nil
+}
+
+// ApplyManifestDefaults sets any of the given command's flags that were not
+// provided on the command line from the project manifest, if present
+func ApplyManifestDefaults(cmd *cobra.Command) error {
+	m, err := LoadManifest()
+	if err != nil || m == nil {
+		return err
+	}
+
+	defaults := map[string]string{
+		"name":       m.Name,
+		"directory":  m.SourceDir(),
+		"runtime":    m.Runtime,
+		"basePath":   m.Proxy.BasePath,
+		"targetPath": m.Proxy.TargetPath,
+		"install":    m.Install,
+	}
+
+	for name, value := range defaults {
+		f := cmd.Flags().Lookup(name)
+		if value == "" || f == nil || f.Changed {
+			continue
+		}
+
+		// set the value directly so the flag still reads as not given
+		if err := f.Value.Set(value); err != nil {
+			return err
+		}
+	}
+
+	if cmd.Flags().Lookup("env-var") != nil {
+		envVars = mergePairs(m.EnvVarsFor(envName), envVars)
+	}
+
+	if cmd.Flags().Lookup("edge-config") != nil {
+		edgeConfigs = mergePairs(m.EdgeConfigsFor(envName), edgeConfigs)
+	}
+
+	if r := m.ReplicasFor(envName); r > 0 {
+		if f := cmd.Flags().Lookup("replicas"); f == nil || !f.Changed {
+			replicas = r
+		}
+	}
+
+	return nil
+}
+
+// mergePairs combines "NAME=VAL" pairs, with overrides replacing defaults of the same name
+func mergePairs(defaults []string, overrides []string) []string {
+	merged := []string{}
+	overridden := map[string]bool{}
+
+	for _, pair := range overrides {
+		overridden[strings.SplitN(pair, "=", 2)[NAME]] = true
+	}
+
+	for _, pair := range defaults {
+		if !overridden[strings.SplitN(pair, "=", 2)[NAME]] {
+			merged = append(merged, pair)
+		}
+	}
+
+	return append(merged, overrides...)
+}
@@ -0,0 +1,355 @@
+// Copyright © 2016 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/30x/shipyardctl/utils"
+	"github.com/ryanuber/columnize"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var importApps []string
+var importParallel int
+
+// appImport an application imported along with others, and its outcome
+type appImport struct {
+	Name      string `json:"application" yaml:"application"`
+	Directory string `json:"directory" yaml:"directory"`
+	Succeeded bool   `json:"succeeded" yaml:"succeeded"`
+	Revision  string `json:"revision,omitempty" yaml:"revision,omitempty"`
+	Reused    bool   `json:"reused,omitempty" yaml:"reused,omitempty"`
+	BuildID   string `json:"buildId,omitempty" yaml:"buildId,omitempty"`
+	Error     string `json:"error,omitempty" yaml:"error,omitempty"`
+
+	manifest *utils.Manifest // the app's own project manifest, if it has one
+	output   []byte          // progress and error output of the import
+}
+
+// isMultiAppImport reports whether several applications should be imported: when
+// given --apps, or when the project manifest lists apps and no single app was named
+func isMultiAppImport(cmd *cobra.Command) (bool, error) {
+	if len(importApps) > 0 {
+		return true, nil
+	}
+
+	if cmd.Flags().Changed("name") || cmd.Flags().Changed("directory") {
+		return false, nil
+	}
+
+	m, err := LoadManifest()
+	if err != nil || m == nil {
+		return false, err
+	}
+
+	return len(m.Apps) > 0, nil
+}
+
+// resolveImportApps finds the directories of the applications to import. Each is
+// named by its own project manifest, or after its directory.
+func resolveImportApps() ([]*appImport, error) {
+	var dirs []string
+
+	m, err := LoadManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(importApps) > 0 {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		if dirs, err = utils.ResolveAppDirs(cwd, importApps); err != nil {
+			return nil, err
+		}
+	} else if dirs, err = m.AppDirs(); err != nil {
+		return nil, err
+	}
+
+	var apps []*appImport
+	names := map[string]string{}
+
+	for _, dir := range dirs {
+		app := &appImport{Name: filepath.Base(dir), Directory: dir}
+
+		path := filepath.Join(dir, utils.ManifestFileName)
+		if _, err := os.Stat(path); err == nil {
+			m, err := utils.LoadManifest(path)
+			if err != nil {
+				return nil, fmt.Errorf("Unable to read project manifest %s: %v", path, err)
+			}
+
+			app.manifest = m
+			if m.Name != "" {
+				app.Name = m.Name
+			}
+		}
+
+		if other, ok := names[app.Name]; ok {
+			return nil, fmt.Errorf("Both %s and %s would be imported as %s", other, dir, app.Name)
+		}
+
+		names[app.Name] = dir
+		apps = append(apps, app)
+	}
+
+	return apps, nil
+}
+
+// importMultipleApps imports each of the applications, at most --parallel at a
+// time, then summarizes the results
+func importMultipleApps(cmd *cobra.Command) {
+	apps, err := resolveImportApps()
+	checkError(err, "Unable to find the applications to import")
+
+	if len(apps) == 0 {
+		checkError(fmt.Errorf("No application directories matched"), "")
+	}
+
+	// log in once up front, the imports themselves can't prompt for credentials
+	if _, status, _ := getAppRevisions(apps[0].Name); !CheckIfAuthn(status) {
+		if _, status, _ = getAppRevisions(apps[0].Name); status == 401 {
+			fmt.Println("Unable to authenticate. Please check your SSO target URL is correct.")
+			fmt.Println("Command failed.")
+			os.Exit(1)
+		}
+	}
+
+	parallel := importParallel
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	fmt.Fprintf(humanOutput(), "Importing %d applications, %d at a time.\n", len(apps), parallel)
+
+	jobs := make(chan *appImport)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for app := range jobs {
+				runAppImport(cmd, app, &mu)
+
+				mu.Lock()
+				if app.Succeeded {
+					fmt.Fprintf(os.Stderr, "[%s] done\n", app.Name)
+				} else {
+					fmt.Fprintf(os.Stderr, "[%s] failed\n", app.Name)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, app := range apps {
+		jobs <- app
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	printImportSummary(apps)
+
+	for _, app := range apps {
+		if !app.Succeeded {
+			os.Exit(1)
+		}
+	}
+}
+
+// runAppImport imports a single application, recording its outcome
+func runAppImport(cmd *cobra.Command, app *appImport, mu *sync.Mutex) {
+	var output bytes.Buffer
+	out := io.Writer(&output)
+	if verbose {
+		out = io.MultiWriter(&output, &prefixWriter{prefix: "[" + app.Name + "] ", w: os.Stderr, mu: mu})
+	}
+
+	job := appImportJob(cmd, app, out)
+	status, err := importApp(job)
+	app.output = output.Bytes()
+
+	switch {
+	case err != nil:
+		app.Error = errorLine(err.Error())
+	case status == 401:
+		app.Error = "Unable to authenticate, please login again"
+	case job.Result != nil && job.Result.Succeeded:
+		app.Succeeded = true
+		app.Revision = job.Result.Revision
+		app.Reused = job.Result.Reused
+	case job.BuildID != "": // detached
+		app.Succeeded = true
+		app.BuildID = job.BuildID
+	case job.Result != nil && job.Result.Error != "":
+		app.Error = errorLine(job.Result.Error)
+	case job.Failure != "":
+		app.Error = job.Failure
+	default:
+		app.Error = "No build result"
+	}
+}
+
+// appImportJob the import of one of several applications. Its own project manifest,
+// or else the shared one, provides the runtime, install command and env vars not
+// given on the command line.
+func appImportJob(cmd *cobra.Command, app *appImport, out io.Writer) *importJob {
+	job := &importJob{
+		Name:      app.Name,
+		Directory: app.Directory,
+		Runtime:   runtime,
+		EnvVars:   envVars,
+		Install:   installCommand,
+		Out:       out,
+		Results:   ioutil.Discard, // summarized once all are done
+	}
+
+	m := app.manifest
+	if m == nil {
+		m = manifest
+	}
+
+	if m != nil {
+		if m.Runtime != "" && !cmd.Flags().Changed("runtime") {
+			job.Runtime = m.Runtime
+		}
+
+		if m.Install != "" && !cmd.Flags().Changed("install") {
+			job.Install = m.Install
+		}
+
+		job.EnvVars = mergePairs(m.EnvVarsFor(""), envVars)
+	}
+
+	return job
+}
+
+// errorLine picks the line of the output most likely to explain a failure:
+// the first command error if any, or else the last line
+func errorLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for _, line := range lines {
+		if strings.HasPrefix(line, "Error: ") {
+			return strings.TrimPrefix(line, "Error: ")
+		}
+
+	}
+
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// printImportSummary outputs the result of each application's import
+func printImportSummary(apps []*appImport) {
+	switch format {
+	case "json":
+		out, err := json.MarshalIndent(apps, "", "  ")
+		checkError(err, "")
+		fmt.Println(string(out))
+		return
+	case "yaml":
+		out, err := yaml.Marshal(apps)
+		checkError(err, "")
+		fmt.Println(string(out))
+		return
+	case "":
+	default:
+		log.Fatalf("Unsupported output format: %s", format)
+	}
+
+	cwd, _ := os.Getwd()
+	lines := []string{"APPLICATION | DIRECTORY | RESULT"}
+	failed := 0
+
+	for _, app := range apps {
+		dir := app.Directory
+		if rel, err := filepath.Rel(cwd, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			dir = rel
+		}
+
+		var result string
+		switch {
+		case app.BuildID != "":
+			result = "build " + app.BuildID + " started"
+		case app.Reused:
+			result = "revision " + app.Revision + " (unchanged)"
+		case app.Succeeded:
+			result = "revision " + app.Revision
+		default:
+			result = "failed: " + app.Error
+			failed++
+		}
+
+		lines = append(lines, app.Name+" | "+dir+" | "+result)
+	}
+
+	fmt.Println()
+	fmt.Println(columnize.SimpleFormat(lines))
+	fmt.Printf("\n%d imported, %d failed\n", len(apps)-failed, failed)
+
+	if verbose {
+		return // the output was already streamed
+	}
+
+	for _, app := range apps {
+		if !app.Succeeded && len(app.output) > 0 {
+			fmt.Fprintf(os.Stderr, "\n--- %s ---\n%s", app.Name, app.output)
+		}
+	}
+}
+
+// prefixWriter writes whole lines with a prefix, keeping concurrent output readable
+type prefixWriter struct {
+	prefix string
+	w      io.Writer
+	mu     *sync.Mutex
+	buf    bytes.Buffer
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf.Write(b)
+
+	for {
+		line, err := p.buf.ReadString('\n')
+		if err != nil { // incomplete line, keep it for the next write
+			p.buf.WriteString(line)
+			return len(b), nil
+		}
+
+		p.mu.Lock()
+		_, err = io.WriteString(p.w, p.prefix+line)
+		p.mu.Unlock()
+
+		if err != nil {
+			return len(b), err
+		}
+	}
+}
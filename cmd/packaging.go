@@ -0,0 +1,246 @@
+// Copyright © 2016 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/30x/shipyardctl/utils"
+	"github.com/ryanuber/columnize"
+)
+
+// packageFile a file to be included in an application archive
+type packageFile struct {
+	Name string // slash separated path relative to the source directory
+	Path string // path on disk
+	Size int64
+	Mode os.FileMode
+}
+
+// excludedPath a path left out of an application archive and the pattern that excluded it
+type excludedPath struct {
+	Name    string
+	Pattern string
+}
+
+// listPackageFiles walks the source directory, splitting its files into those
+// to be archived and those excluded by the default and .shipyardignore patterns
+func listPackageFiles(dir string) ([]packageFile, []excludedPath, error) {
+	matcher, err := utils.LoadIgnoreMatcher(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return walkPackageFiles(dir, matcher)
+}
+
+// walkPackageFiles walks the source directory, splitting its files into those
+// to be archived and those excluded by the given matcher
+func walkPackageFiles(dir string, matcher *utils.IgnoreMatcher) ([]packageFile, []excludedPath, error) {
+	var files []packageFile
+	var excluded []excludedPath
+
+	root, err := filepath.Abs(dir)
+	if err == nil {
+		root, err = filepath.EvalSymlinks(root)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// walk the resolved root, filepath.Walk won't descend into a symlinked one
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		if rel == "." {
+			return nil
+		}
+
+		name := filepath.ToSlash(rel)
+
+		// follow symlinks to files within the source directory, but never into directories
+		if info.Mode()&os.ModeSymlink != 0 {
+			if info, err = os.Stat(path); os.IsNotExist(err) {
+				excluded = append(excluded, excludedPath{name, "broken symlink"})
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			if real, err := filepath.EvalSymlinks(path); err != nil {
+				return err
+			} else if !withinDir(root, real) {
+				excluded = append(excluded, excludedPath{name, "symlink outside the source directory"})
+				return nil
+			}
+
+			if info.IsDir() {
+				excluded = append(excluded, excludedPath{name + "/", "symlinked directory"})
+				return nil
+			}
+		}
+
+		if ignored, pattern := matcher.Match(name, info.IsDir()); ignored {
+			if info.IsDir() {
+				excluded = append(excluded, excludedPath{name + "/", pattern})
+				return filepath.SkipDir
+			}
+
+			excluded = append(excluded, excludedPath{name, pattern})
+			return nil
+		}
+
+		if info.Mode().IsRegular() {
+			files = append(files, packageFile{name, path, info.Size(), info.Mode()})
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return files, excluded, nil
+}
+
+// packageModTime the modification time of every archived file, so the same
+// files always produce the same archive
+var packageModTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// writePackage zips the given files into target, relative to the archive root
+func writePackage(files []packageFile, target string) error {
+	zipfile, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer zipfile.Close()
+
+	archive := zip.NewWriter(zipfile)
+
+	for _, f := range files {
+		header := &zip.FileHeader{
+			Name:   f.Name,
+			Method: zip.Deflate,
+		}
+		header.SetMode(f.Mode)
+		header.SetModTime(packageModTime)
+
+		writer, err := archive.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Open(f.Path)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(writer, file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// contentHash computes a deterministic hash of the packaged files and the
+// build settings, independent of file order and timestamps
+func contentHash(files []packageFile, runtime string, envVars []string, install string) (string, error) {
+	sorted := append([]packageFile{}, files...)
+	sort.Sort(byPackageName(sorted))
+
+	vars := append([]string{}, envVars...)
+	sort.Strings(vars)
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "runtime %s\n", runtime)
+	if install != "" {
+		fmt.Fprintf(hash, "install %q\n", install)
+	}
+	for _, v := range vars {
+		fmt.Fprintf(hash, "env %q\n", v)
+	}
+
+	for _, f := range sorted {
+		fmt.Fprintf(hash, "file %q %o %d\n", f.Name, f.Mode.Perm(), f.Size)
+
+		file, err := os.Open(f.Path)
+		if err != nil {
+			return "", err
+		}
+
+		_, err = io.Copy(hash, file)
+		file.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+type byPackageName []packageFile
+
+func (f byPackageName) Len() int           { return len(f) }
+func (f byPackageName) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+func (f byPackageName) Less(i, j int) bool { return f[i].Name < f[j].Name }
+
+// printPackageFiles lists the files that would be archived and their total size
+func printPackageFiles(files []packageFile) {
+	var total int64
+	lines := []string{"FILE | SIZE"}
+
+	for _, f := range files {
+		lines = append(lines, f.Name+" | "+formatBytes(f.Size))
+		total += f.Size
+	}
+
+	fmt.Println(columnize.SimpleFormat(lines))
+	fmt.Printf("\n%d files, %s total\n", len(files), formatBytes(total))
+}
+
+// printExcludedPaths lists the paths left out of the archive and the pattern excluding them
+func printExcludedPaths(w io.Writer, excluded []excludedPath) {
+	if len(excluded) == 0 {
+		return
+	}
+
+	lines := []string{"EXCLUDED | PATTERN"}
+	for _, e := range excluded {
+		lines = append(lines, e.Name+" | "+e.Pattern)
+	}
+
+	fmt.Fprintln(w, columnize.SimpleFormat(lines))
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/30x/shipyardctl/utils"
+)
+
+// writeTree creates the files, with their content, under dir
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "shipyardctl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func TestWalkPackageFilesSymlinks(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	outside := tempDir(t)
+	defer os.RemoveAll(outside)
+
+	src := filepath.Join(dir, "src")
+	writeTree(t, src, map[string]string{
+		"index.js":       "",
+		"lib/util.js":    "",
+		".env":           "SECRET=1",
+		"node_modules/x": "",
+	})
+	writeTree(t, outside, map[string]string{"passwd": ""})
+
+	links := map[string]string{
+		"linked.js":   "index.js",                           // file within the source
+		"broken.js":   "missing.js",                         // target doesn't exist
+		"escape":      filepath.Join(outside, "passwd"),     // file outside the source
+		"escape-rel":  filepath.Join("..", "..", "outside"), // relative, outside the source
+		"libdir":      "lib",                                // directory within the source
+		"outside-dir": outside,                              // directory outside the source
+	}
+
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(src, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, excluded, err := walkPackageFiles(src, utils.NewIgnoreMatcher(utils.DefaultIgnorePatterns))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+
+	if want := []string{"index.js", "lib/util.js", "linked.js"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("packaged %v, want %v", names, want)
+	}
+
+	reasons := map[string]string{}
+	for _, e := range excluded {
+		reasons[e.Name] = e.Pattern
+	}
+
+	want := map[string]string{
+		".env":          ".env",
+		"node_modules/": "node_modules/",
+		"broken.js":     "broken symlink",
+		"escape":        "symlink outside the source directory",
+		"escape-rel":    "broken symlink",
+		"libdir/":       "symlinked directory",
+		"outside-dir":   "symlink outside the source directory",
+	}
+
+	if !reflect.DeepEqual(reasons, want) {
+		t.Errorf("excluded %v, want %v", reasons, want)
+	}
+}
+
+func TestWalkPackageFilesSymlinkedRoot(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	writeTree(t, filepath.Join(dir, "real"), map[string]string{"index.js": "", "a/b.js": ""})
+	if err := os.Symlink("real", filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	// files within a source directory reached through a symlink are still within it
+	if err := os.Symlink("a/b.js", filepath.Join(dir, "real", "b.js")); err != nil {
+		t.Fatal(err)
+	}
+
+	files, excluded, err := walkPackageFiles(filepath.Join(dir, "link"), utils.NewIgnoreMatcher(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 3 || len(excluded) != 0 {
+		t.Errorf("packaged %v, excluded %v, want 3 files and no exclusions", files, excluded)
+	}
+}
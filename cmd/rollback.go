@@ -0,0 +1,176 @@
+// Copyright © 2016 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/30x/shipyardctl/utils"
+	"github.com/spf13/cobra"
+)
+
+var toRevision int
+
+// rollbackCmd represents the rollback command
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback [command]",
+	Short: "returns a Shipyard artifact to a previous state",
+	Long:  `This command, when paired with the proper subcommand, will roll back the respective artifact.`,
+}
+
+var rollbackDeploymentCmd = &cobra.Command{
+	Use:   "deployment -o {org} -e {env} -n {name} [--to-revision {revision}]",
+	Short: "returns an active deployment to its previously deployed revision",
+	Long: `Given the name of an active deployment, this will update it back to the revision
+that was deployed before its current one.
+
+The previous revision is taken from the locally saved deployment history (see
+"shipyardctl history"). When no other revision of it was deployed from this machine,
+the highest imported revision below the current one is used instead. Use
+--to-revision to choose the revision explicitly.
+
+Example of use:
+$ shipyardctl rollback deployment -o acme -e test -n example
+
+#Roll back to a specific revision and wait for it to be available
+$ shipyardctl rollback deployment -o acme -e test -n example --to-revision 3 --wait`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := RequireAuthToken(); err != nil {
+			return err
+		}
+
+		if err := RequireOrgName(); err != nil {
+			return err
+		}
+
+		if err := RequireEnvName(); err != nil {
+			return err
+		}
+
+		if err := RequireAppName(); err != nil {
+			return err
+		}
+
+		if cmd.Flags().Changed("to-revision") && toRevision < 1 {
+			return fmt.Errorf("--to-revision must be a positive revision number.")
+		}
+
+		MakeBuildPath()
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		shipyardEnv := orgName + ":" + envName
+
+		dep, status, err := getDeploymentState(shipyardEnv, appName)
+		if !CheckIfAuthn(status) {
+			// retry once more
+			dep, status, err = getDeploymentState(shipyardEnv, appName)
+		}
+		checkError(err, "")
+
+		metadata, _ := dep["metadata"].(map[string]interface{})
+		labels, _ := metadata["labels"].(map[string]interface{})
+		current := fmt.Sprint(templateParseRevision(labels))
+
+		target := ""
+		if cmd.Flags().Changed("to-revision") {
+			target = strconv.Itoa(toRevision)
+		} else {
+			target, err = previousRevision(appName, current)
+			checkError(err, "")
+		}
+
+		if target == current {
+			fmt.Printf("%s in %s is already at revision %s\n", appName, shipyardEnv, current)
+			return
+		}
+
+		revision, err := strconv.Atoi(target)
+		checkError(err, "Invalid revision "+target)
+
+		fmt.Fprintf(humanOutput(), "Rolling back %s in %s from revision %s to %s\n", appName, shipyardEnv, current, target)
+
+		revision32 := int32(revision)
+		updateData := deploymentPatch{Revision: &revision32}
+
+		status = updateDeployment(shipyardEnv, appName, updateData)
+		if !CheckIfAuthn(status) {
+			// retry once more
+			status = updateDeployment(shipyardEnv, appName, updateData)
+			if status == 401 {
+				fmt.Println("Unable to authenticate. Please check your SSO target URL is correct.")
+				fmt.Println("Command failed.")
+			}
+		}
+
+		waitOrExit(status, shipyardEnv, appName, target, -1)
+	},
+}
+
+// previousRevision finds the revision deployed to the current environment before
+// the current one, falling back to the highest imported revision below it
+func previousRevision(name string, current string) (string, error) {
+	entries, err := utils.ListHistory(utils.HistoryDeployments, orgName, name)
+	if err != nil {
+		return "", fmt.Errorf("Unable to read deployment history: %v", err)
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.Env == envName && e.Succeeded && e.Revision != "" && e.Revision != current {
+			return e.Revision, nil
+		}
+	}
+
+	currentNum, err := strconv.Atoi(current)
+	if err != nil {
+		return "", fmt.Errorf("Unable to determine the current revision of %s. Use --to-revision.", name)
+	}
+
+	revisions, status, err := getAppRevisions(name)
+	if !CheckIfAuthn(status) {
+		// retry once more
+		revisions, status, err = getAppRevisions(name)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	for i := len(revisions) - 1; i >= 0; i-- {
+		if num, _ := strconv.Atoi(revisions[i].Revision); num < currentNum {
+			fmt.Fprintf(os.Stderr, "No earlier deployment of %s in %s is saved locally, using the previous imported revision %d.\n", name, envName, num)
+			return revisions[i].Revision, nil
+		}
+	}
+
+	return "", fmt.Errorf("No revision of %s to roll back to was found. Use --to-revision.", name)
+}
+
+func init() {
+	RootCmd.AddCommand(rollbackCmd)
+
+	rollbackCmd.AddCommand(rollbackDeploymentCmd)
+	rollbackDeploymentCmd.Flags().StringVarP(&orgName, "org", "o", "", "Apigee organization name")
+	rollbackDeploymentCmd.Flags().StringVarP(&envName, "env", "e", "", "Apigee environment name")
+	rollbackDeploymentCmd.Flags().StringVarP(&appName, "name", "n", "", "name of the active deployment to roll back")
+	rollbackDeploymentCmd.Flags().IntVar(&toRevision, "to-revision", 0, "revision to roll back to, instead of the previously deployed one")
+	rollbackDeploymentCmd.Flags().BoolVar(&wait, "wait", false, "wait until the rolled back revision is available")
+	rollbackDeploymentCmd.Flags().DurationVar(&waitTimeout, "timeout", 5*time.Minute, "how long to --wait before failing")
+	rollbackDeploymentCmd.Flags().StringVar(&format, "format", "", "output format for response: json, yaml, raw")
+}
@@ -37,6 +37,7 @@ var enroberPath string
 var basePath string
 var pubKey string
 var envVars []string
+var envFiles []string
 var sso_target string
 
 var appName string
@@ -48,8 +49,9 @@ var force bool
 var edgeConfigs []string
 var format string
 var verbose bool
+var dryRun bool
+var replicas = defaultReplicas
 
-var supportedRuntimes = "node"
 var config *utils.Config
 
 const defaultReplicas = 1
@@ -62,6 +64,9 @@ var RootCmd = &cobra.Command{
 
 Pair this command with any of the available functions for applications, images,
 bundles, environments or deployments.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return initApp()
+	},
 }
 
 // Execute adds all child commands to the root command sets flags appropriately.
@@ -76,42 +81,8 @@ func Execute() {
 func init() {
 	RootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Print the request & response headers from API calls")
 	RootCmd.PersistentFlags().StringVarP(&authToken, "token", "t", "", "Apigee auth token. Required. Or place in APIGEE_TOKEN.")
-
-	// check if there is a config file present
-	check, err := utils.ConfigExists()
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(-1)
-	}
-
-	// read environment variables or use defaults
-	checkEnvironmentOrDefault()
-
-	// make a new config file because there wasn't one
-	if !check {
-		fmt.Println("No config file present. Creating one now.")
-
-		err = utils.InitNewConfigFile("default", sso_target, clusterTarget)
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(-1)
-		}
-
-		fmt.Printf("Created new config file.\n\n")
-	}
-
-	// read config into memory
-	config, err = utils.LoadConfig()
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(-1)
-	}
-
-	// environment overrides config, so check there first before setting vars based on config
-	checkEnvironmentOrConfig()
-
-	// Enrober API path, appended to clusterTarget before each API call
-	enroberPath = "/environments"
+	RootCmd.PersistentFlags().StringVar(&manifestPath, "manifest", "", "Path to the project manifest. Defaults to the nearest "+utils.ManifestFileName)
+	RootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Validate and print the requests that would change anything, without sending them")
 }
 
 // PrintDebugRequest used to print the request when using debug
@@ -0,0 +1,156 @@
+// Copyright © 2016 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/30x/shipyardctl/utils"
+	"github.com/spf13/cobra"
+)
+
+// runtimesPath build service path of the runtime catalog, appended to clusterTarget
+const runtimesPath = "/runtimes"
+
+var runtimeCatalog utils.RuntimeCatalog
+var runtimeCatalogSource string
+var runtimeCatalogMu sync.Mutex // applications may be imported concurrently
+
+var getRuntimesCmd = &cobra.Command{
+	Use:   "runtimes",
+	Short: "retrieve the runtimes applications can be imported with",
+	Long: `This retrieves the runtimes, and their versions, supported by the build service
+of the current cluster. When the cluster can't be reached, the last retrieved list
+is used, or failing that the list built into shipyardctl.
+
+Example of use:
+
+$ shipyardctl get runtimes`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if format == "" {
+			format = "get-runtimes"
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		catalog, source := loadRuntimeCatalog()
+
+		js, err := json.Marshal(catalog)
+		checkError(err, "")
+
+		out, err := formatOutput(format, ioutil.NopCloser(bytes.NewReader(js)))
+		checkError(err, "")
+
+		if format == "get-runtimes" {
+			fmt.Printf("\nAvailable runtimes (%s):\n", source)
+		}
+
+		fmt.Println(string(out))
+	},
+}
+
+// loadRuntimeCatalog retrieves the runtime catalog from the cluster, falling back
+// to the cached one and then the embedded one. It also describes where it came from.
+func loadRuntimeCatalog() (utils.RuntimeCatalog, string) {
+	runtimeCatalogMu.Lock()
+	defer runtimeCatalogMu.Unlock()
+
+	if runtimeCatalog != nil {
+		return runtimeCatalog, runtimeCatalogSource
+	}
+
+	catalog, err := fetchRuntimeCatalog()
+	if err == nil {
+		if err = utils.SaveCachedRuntimes(clusterTarget, catalog); err != nil && debug {
+			fmt.Println("Unable to cache runtimes:", err)
+		}
+
+		runtimeCatalog, runtimeCatalogSource = catalog, "from "+clusterTarget
+		return runtimeCatalog, runtimeCatalogSource
+	}
+
+	if debug {
+		fmt.Println("Unable to retrieve runtimes:", err)
+	}
+
+	// don't retry the cluster for every application, fall back for the life of the process
+	cached, fetched, err := utils.LoadCachedRuntimes(clusterTarget)
+	if err == nil && cached != nil {
+		runtimeCatalog, runtimeCatalogSource = cached, "cached "+fetched.Format(time.RFC3339)
+	} else {
+		runtimeCatalog, runtimeCatalogSource = utils.EmbeddedRuntimes, "built in"
+	}
+
+	return runtimeCatalog, runtimeCatalogSource
+}
+
+func fetchRuntimeCatalog() (utils.RuntimeCatalog, error) {
+	req, err := http.NewRequest("GET", clusterTarget+runtimesPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if debug {
+		PrintDebugRequest(req)
+	}
+
+	if authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+authToken)
+	}
+
+	response, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if debug {
+		PrintDebugResponse(response)
+	}
+
+	defer response.Body.Close()
+	if response.StatusCode != 200 {
+		return nil, fmt.Errorf("Received %s", response.Status)
+	}
+
+	catalog := utils.RuntimeCatalog{}
+	err = json.NewDecoder(response.Body).Decode(&catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(catalog) == 0 {
+		return nil, fmt.Errorf("Received an empty runtime catalog")
+	}
+
+	return catalog, nil
+}
+
+// validateRuntime checks a "name[:version]" runtime against the runtime catalog
+func validateRuntime(runtime string) error {
+	catalog, _ := loadRuntimeCatalog()
+	return catalog.Validate(runtime)
+}
+
+func init() {
+	getCmd.AddCommand(getRuntimesCmd)
+	getRuntimesCmd.Flags().StringVar(&format, "format", "", "output format: json,yaml,raw")
+}
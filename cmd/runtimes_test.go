@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/30x/shipyardctl/utils"
+)
+
+func TestLoadRuntimeCatalogFallbackOnce(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	defer func(target string, c *http.Client) {
+		clusterTarget, client = target, c
+		runtimeCatalog, runtimeCatalogSource = nil, ""
+	}(clusterTarget, client)
+	clusterTarget, client = server.URL, http.DefaultClient
+
+	for i := 0; i < 3; i++ {
+		catalog, source := loadRuntimeCatalog()
+		if len(catalog) != len(utils.EmbeddedRuntimes) || source != "built in" {
+			t.Errorf("loaded %d runtimes %s, want the built in catalog", len(catalog), source)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("requested the runtimes %d times, want 1", requests)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright © 2016 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// scaleCmd represents the scale command
+var scaleCmd = &cobra.Command{
+	Use:   "scale [command]",
+	Short: "changes the number of replicas of a Shipyard artifact",
+	Long:  `This command, when paired with the proper subcommand, will scale the respective artifact.`,
+}
+
+var scaleDeploymentCmd = &cobra.Command{
+	Use:   "deployment -o {org} -e {env} -n {name} --replicas {count}",
+	Short: "changes the number of replicas of an active deployment",
+	Long: `Given the name of an active deployment, this will change how many replicas of
+it are running, leaving its revision and environment variables as they are.
+
+Example of use:
+$ shipyardctl scale deployment -o acme -e test -n example --replicas 3
+
+#Wait until exactly that many replicas are available
+$ shipyardctl scale deployment -o acme -e test -n example --replicas 3 --wait --timeout 2m`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := RequireAuthToken(); err != nil {
+			return err
+		}
+
+		if err := RequireOrgName(); err != nil {
+			return err
+		}
+
+		if err := RequireEnvName(); err != nil {
+			return err
+		}
+
+		if err := RequireAppName(); err != nil {
+			return err
+		}
+
+		if !cmd.Flags().Changed("replicas") {
+			return fmt.Errorf("Missing required flag '--replicas'.")
+		}
+
+		return RequireReplicas(0)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		shipyardEnv := orgName + ":" + envName
+		replicas32 := int32(replicas)
+		updateData := deploymentPatch{Replicas: &replicas32}
+
+		status := updateDeployment(shipyardEnv, appName, updateData)
+		if !CheckIfAuthn(status) {
+			// retry once more
+			status = updateDeployment(shipyardEnv, appName, updateData)
+			if status == 401 {
+				fmt.Println("Unable to authenticate. Please check your SSO target URL is correct.")
+				fmt.Println("Command failed.")
+			}
+		}
+
+		waitOrExit(status, shipyardEnv, appName, "", replicas)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(scaleCmd)
+
+	scaleCmd.AddCommand(scaleDeploymentCmd)
+	scaleDeploymentCmd.Flags().StringVarP(&orgName, "org", "o", "", "Apigee organization name")
+	scaleDeploymentCmd.Flags().StringVarP(&envName, "env", "e", "", "Apigee environment name")
+	scaleDeploymentCmd.Flags().StringVarP(&appName, "name", "n", "", "name of the active deployment to scale")
+	scaleDeploymentCmd.Flags().IntVar(&replicas, "replicas", defaultReplicas, "number of replicas to run")
+	scaleDeploymentCmd.Flags().BoolVar(&wait, "wait", false, "wait until exactly that many replicas are available")
+	scaleDeploymentCmd.Flags().DurationVar(&waitTimeout, "timeout", 5*time.Minute, "how long to --wait before failing")
+	scaleDeploymentCmd.Flags().StringVar(&format, "format", "", "output format for response: json, yaml, raw")
+}
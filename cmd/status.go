@@ -41,7 +41,7 @@ $ shipyardctl get status`,
 			PrintDebugRequest(kilnReq)
 		}
 
-		kilnRes, err := http.DefaultClient.Do(kilnReq)
+		kilnRes, err := client.Do(kilnReq)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -62,7 +62,7 @@ $ shipyardctl get status`,
 			PrintDebugRequest(enroberReq)
 		}
 
-		enroberRes, err := http.DefaultClient.Do(enroberReq)
+		enroberRes, err := client.Do(enroberReq)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -96,14 +96,33 @@ var GET_APPS = `NAME
 {{end}}`
 
 var GET_APP_REV = `REVISION | CREATED
-{{.revision}} | {{.created}}`
+{{.revision}} | {{with .created}}{{.}}{{end}}`
 
 var GET_DEPS = `APPLICATION | CREATED | DEPLOYMENT REVISION | AVAILABLE
-{{ range .items }}{{.metadata.name}}:{{revision .metadata.labels}} | {{.metadata.creationTimestamp}} | {{.metadata.generation}} | {{status .status.conditions}}
+{{ range .items }}{{.metadata.name}}:{{revision .metadata.labels}} | {{with .metadata.creationTimestamp}}{{.}}{{end}} | {{with .metadata.generation}}{{.}}{{end}} | {{status .status.conditions}}
 {{end}}`
 
 var GET_DEP = `APPLICATION | CREATED | DEPLOYMENT REVISION | AVAILABLE
-{{.metadata.name}}:{{revision .metadata.labels}} | {{.metadata.creationTimestamp}} | {{.metadata.generation}} | {{status .status.conditions}}`
+{{.metadata.name}}:{{revision .metadata.labels}} | {{with .metadata.creationTimestamp}}{{.}}{{end}} | {{with .metadata.generation}}{{.}}{{end}} | {{status .status.conditions}}`
 
 var GET_ENV = `NAME | EDGE HOSTS | API SECRET
-{{.name}} | {{.edgeHosts}} | {{.apiSecret}}`
+{{.name}} | {{with .edgeHosts}}{{.}}{{end}} | {{with .apiSecret}}{{.}}{{end}}`
+
+var GET_BUILD = `ID | APPLICATION | STATUS | REVISION
+{{.id}} | {{with .application}}{{.}}{{end}} | {{.status}} | {{with .revision}}{{.}}{{end}}`
+
+var HISTORY_BUILDS = `ID | ORG | APPLICATION | REVISION | RESULT
+{{ range .}}{{.id}} | {{.org}} | {{.app}} | {{with .revision}}{{.}}{{end}} | {{if .succeeded}}succeeded{{else}}failed{{end}}
+{{end}}`
+
+var HISTORY_DEPLOYMENTS = `ID | ENVIRONMENT | APPLICATION | ACTION | REVISION | USER | ENV VARS | RESULT
+{{ range .}}{{.id}} | {{with .env}}{{.}}{{end}} | {{.app}} | {{with .action}}{{.}}{{end}} | {{with .revision}}{{.}}{{end}} | {{with .user}}{{.}}{{end}} | {{if .envChanges}}{{join .envChanges}}{{end}} | {{if .succeeded}}succeeded{{else}}failed{{end}}
+{{end}}`
+
+var GET_ENV_VARS = `NAME | VALUE
+{{ range .}}{{.Name}} | {{if .valueFrom}}{{with .valueFrom.edgeConfigRef}}edgeConfigRef {{.Name}}:{{.Key}}{{else}}valueFrom{{range $source, $ref := .valueFrom}} {{$source}}{{end}}{{end}}{{else}}{{with .Value}}{{.}}{{end}}{{end}}
+{{end}}`
+
+var GET_RUNTIMES = `NAME | VERSIONS | DEFAULT
+{{ range .}}{{.name}} | {{join .versions}} | {{with .default}}{{.}}{{end}}
+{{end}}`
@@ -0,0 +1,175 @@
+// Copyright © 2016 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+var uploadRetries int
+
+// formField a multipart form field sent along with an application archive
+type formField struct {
+	Name  string
+	Value string
+}
+
+// uploadArchive POSTs the archive and form fields as a multipart body streamed from
+// disk. Connection failures are retried from the start of the archive, reporting
+// each retry to out.
+func uploadArchive(url string, archive string, fields []formField, out io.Writer, progress bool) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		response, err := uploadArchiveOnce(url, archive, fields, progress)
+		if err == nil || attempt > uploadRetries {
+			return response, err
+		}
+
+		fmt.Fprintf(out, "Upload failed: %v\nRetrying upload (%d/%d)\n", err, attempt, uploadRetries)
+		time.Sleep(time.Duration(attempt) * 2 * time.Second)
+	}
+}
+
+func uploadArchiveOnce(url string, archive string, fields []formField, showProgress bool) (*http.Response, error) {
+	file, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	// everything but the archive content is small, so it is written up front
+	// to learn the content length, then the archive is streamed in between
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	if _, err = writer.CreateFormFile("file", filepath.Base(archive)); err != nil {
+		return nil, err
+	}
+	head := form.Len()
+
+	for _, field := range fields {
+		if err = writer.WriteField(field.Name, field.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = writer.Close(); err != nil {
+		return nil, err
+	}
+
+	length := int64(form.Len()) + info.Size()
+	body := io.MultiReader(bytes.NewReader(form.Bytes()[:head]), file, bytes.NewReader(form.Bytes()[head:]))
+	progress := newProgressReader(body, length)
+	progress.enabled = progress.enabled && showProgress
+
+	req, err := http.NewRequest("POST", url, progress)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = length
+
+	if debug {
+		PrintDebugRequest(req)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+authToken)
+	req.Header.Add("Content-Type", writer.FormDataContentType())
+
+	response, err := client.Do(req)
+	progress.Finish()
+
+	return response, err
+}
+
+// progressReader renders a progress bar while the wrapped reader is consumed,
+// as long as stderr is a terminal
+type progressReader struct {
+	reader   io.Reader
+	total    int64
+	read     int64
+	start    time.Time
+	rendered time.Time
+	enabled  bool
+}
+
+func newProgressReader(reader io.Reader, total int64) *progressReader {
+	return &progressReader{
+		reader:  reader,
+		total:   total,
+		start:   time.Now(),
+		enabled: !debug && terminal.IsTerminal(int(os.Stderr.Fd())),
+	}
+}
+
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.reader.Read(buf)
+	p.read += int64(n)
+
+	if p.enabled && time.Since(p.rendered) > 200*time.Millisecond {
+		p.render()
+	}
+
+	return n, err
+}
+
+// Finish renders the final state of the progress bar and ends its line
+func (p *progressReader) Finish() {
+	if p.enabled && p.read > 0 {
+		p.render()
+		fmt.Fprintln(os.Stderr)
+		p.enabled = false
+	}
+}
+
+func (p *progressReader) render() {
+	const width = 30
+
+	p.rendered = time.Now()
+	elapsed := p.rendered.Sub(p.start).Seconds()
+
+	fraction := 1.0
+	if p.total > 0 {
+		fraction = float64(p.read) / float64(p.total)
+	}
+
+	filled := int(fraction * width)
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", width-filled)
+
+	rate, eta := 0.0, "--"
+	if elapsed > 0 {
+		rate = float64(p.read) / elapsed
+	}
+
+	if rate > 0 {
+		remaining := time.Duration(float64(p.total-p.read)/rate) * time.Second
+		eta = remaining.String()
+	}
+
+	fmt.Fprintf(os.Stderr, "\rUploading [%s] %3.0f%% %s/%s %s/s ETA %s   ",
+		bar, fraction*100, formatBytes(p.read), formatBytes(p.total), formatBytes(int64(rate)), eta)
+}
@@ -0,0 +1,379 @@
+// Copyright © 2016 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/30x/shipyardctl/utils"
+	"github.com/spf13/cobra"
+)
+
+// packageJSON the parts of a package.json checked before import
+type packageJSON struct {
+	Name         string            `json:"name"`
+	Main         string            `json:"main"`
+	Scripts      map[string]string `json:"scripts"`
+	Engines      map[string]string `json:"engines"`
+	Dependencies map[string]string `json:"dependencies"`
+}
+
+// npmLockfile the parts of a package-lock.json or npm-shrinkwrap.json checked before import
+type npmLockfile struct {
+	Dependencies map[string]struct {
+		Version string `json:"version"`
+	} `json:"dependencies"`
+	Packages map[string]struct {
+		Version string `json:"version"`
+	} `json:"packages"`
+}
+
+// ValidationIssue a single problem found in the application source
+type ValidationIssue struct {
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+// ValidationReport the result of validating an application source directory
+type ValidationReport struct {
+	Directory string            `json:"directory"`
+	Runtime   string            `json:"runtime"`
+	Valid     bool              `json:"valid"`
+	Errors    []ValidationIssue `json:"errors"`
+	Warnings  []ValidationIssue `json:"warnings"`
+}
+
+var skipValidation bool
+
+func (r *ValidationReport) addError(check string, format string, args ...interface{}) {
+	r.Errors = append(r.Errors, ValidationIssue{check, fmt.Sprintf(format, args...)})
+}
+
+func (r *ValidationReport) addWarning(check string, format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, ValidationIssue{check, fmt.Sprintf(format, args...)})
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [command]",
+	Short: "validates an artifact before it is imported",
+	Long:  `This command, when paired with the proper subcommand, will check the respective artifact locally.`,
+}
+
+var validateAppCmd = &cobra.Command{
+	Use:   "application --directory {dir} --runtime {runtime}[:{version}]",
+	Short: "checks application source before import",
+	Long: `This checks the Node.js application source that would be uploaded by
+'shipyardctl import application', without contacting Shipyard:
+
+  - package.json is present and valid JSON
+  - there is a start script, or a server.js, and the file it runs exists
+  - engines.node allows the version of the --runtime
+  - package-lock.json, npm-shrinkwrap.json or yarn.lock agree with package.json
+
+The same checks run before every import, unless --skip-validation is given.
+
+Example of use:
+
+$ shipyardctl validate application --directory . --runtime node:4 --format json`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := ApplyManifestDefaults(cmd); err != nil {
+			return err
+		}
+
+		if err := RequireDirectory(); err != nil {
+			return err
+		}
+
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		files, _, err := listPackageFiles(directory)
+		checkError(err, "Unable to read application source")
+
+		report := validateNodeApp(files, runtime)
+		report.Directory = directory
+
+		out, err := formatValidationReport(report, format)
+		checkError(err, "")
+
+		fmt.Print(string(out))
+
+		if !report.Valid {
+			os.Exit(1)
+		}
+	},
+}
+
+// validateNodeApp checks the files to be uploaded form a runnable Node.js application
+func validateNodeApp(files []packageFile, runtime string) *ValidationReport {
+	report := &ValidationReport{Runtime: runtime, Errors: []ValidationIssue{}, Warnings: []ValidationIssue{}}
+	defer func() {
+		report.Valid = len(report.Errors) == 0
+	}()
+
+	byName := map[string]packageFile{}
+	for _, f := range files {
+		byName[f.Name] = f
+	}
+
+	f, ok := byName["package.json"]
+	if !ok {
+		report.addError("package.json", "No package.json found at the root of the application, or it is excluded from upload")
+		return report
+	}
+
+	data, err := ioutil.ReadFile(f.Path)
+	if err != nil {
+		report.addError("package.json", "Unable to read package.json: %v", err)
+		return report
+	}
+
+	pkg := packageJSON{}
+	if err = json.Unmarshal(data, &pkg); err != nil {
+		report.addError("package.json", "package.json is not valid JSON: %v", err)
+		return report
+	}
+
+	validateStart(report, pkg, byName)
+	validateEngines(report, pkg, runtime)
+	validateLockfiles(report, pkg, byName)
+
+	return report
+}
+
+func validateStart(report *ValidationReport, pkg packageJSON, files map[string]packageFile) {
+	if pkg.Main != "" && resolveModule(pkg.Main, files) == "" {
+		report.addWarning("main", "The main file %q does not exist", pkg.Main)
+	}
+
+	start, ok := pkg.Scripts["start"]
+	if !ok || strings.TrimSpace(start) == "" {
+		// npm start defaults to "node server.js"
+		if _, ok := files["server.js"]; !ok {
+			report.addError("start", "package.json has no start script and there is no server.js")
+		}
+
+		return
+	}
+
+	fields := strings.Fields(start)
+	if len(fields) < 2 || (fields[0] != "node" && fields[0] != "nodejs") {
+		return // not something we can check
+	}
+
+	for _, arg := range fields[1:] {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+
+		if resolveModule(arg, files) == "" {
+			report.addError("start", "The start script runs %q, which does not exist", arg)
+		}
+
+		return
+	}
+}
+
+// resolveModule finds the file node would load for the given path, or "" if there is none
+func resolveModule(name string, files map[string]packageFile) string {
+	name = path.Clean(strings.TrimPrefix(filepath.ToSlash(name), "./"))
+
+	for _, candidate := range []string{name, name + ".js", name + ".json", path.Join(name, "index.js")} {
+		if _, ok := files[candidate]; ok {
+			return candidate
+		}
+	}
+
+	return ""
+}
+
+func validateEngines(report *ValidationReport, pkg packageJSON, runtime string) {
+	runtimeSplit := strings.SplitN(runtime, ":", 2)
+	if len(runtimeSplit) < 2 || runtimeSplit[1] == "" {
+		return // no version to check against
+	}
+
+	engine, ok := pkg.Engines["node"]
+	if !ok {
+		report.addWarning("engines", "package.json has no engines.node, the application will run on %s", runtime)
+		return
+	}
+
+	allowed, err := utils.RangeAllows(engine, runtimeSplit[1])
+	if err != nil {
+		report.addWarning("engines", "Unable to interpret engines.node %q: %v", engine, err)
+		return
+	}
+
+	if !allowed {
+		report.addError("engines", "engines.node %q does not allow the runtime %s", engine, runtime)
+	}
+}
+
+func validateLockfiles(report *ValidationReport, pkg packageJSON, files map[string]packageFile) {
+	var found []string
+
+	for _, name := range []string{"npm-shrinkwrap.json", "package-lock.json"} {
+		f, ok := files[name]
+		if !ok {
+			continue
+		}
+
+		found = append(found, name)
+		validateNpmLockfile(report, pkg, f)
+	}
+
+	if f, ok := files["yarn.lock"]; ok {
+		found = append(found, "yarn.lock")
+		validateYarnLockfile(report, pkg, f)
+	}
+
+	if len(found) > 1 {
+		report.addWarning("lockfile", "Found more than one lockfile: %s", strings.Join(found, ", "))
+	}
+}
+
+func validateNpmLockfile(report *ValidationReport, pkg packageJSON, f packageFile) {
+	data, err := ioutil.ReadFile(f.Path)
+	if err != nil {
+		report.addError("lockfile", "Unable to read %s: %v", f.Name, err)
+		return
+	}
+
+	lock := npmLockfile{}
+	if err = json.Unmarshal(data, &lock); err != nil {
+		report.addError("lockfile", "%s is not valid JSON: %v", f.Name, err)
+		return
+	}
+
+	for _, dep := range sortedKeys(pkg.Dependencies) {
+		version := ""
+		if entry, ok := lock.Packages["node_modules/"+dep]; ok {
+			version = entry.Version
+		} else if entry, ok := lock.Dependencies[dep]; ok {
+			version = entry.Version
+		} else {
+			report.addWarning("lockfile", "%s is missing dependency %q, run npm install to update it", f.Name, dep)
+			continue
+		}
+
+		// only plain semver ranges can be compared, not urls, tags or paths
+		if allowed, err := utils.RangeAllows(pkg.Dependencies[dep], version); err == nil && !allowed {
+			report.addWarning("lockfile", "%s locks %s@%s, which does not satisfy %q in package.json", f.Name, dep, version, pkg.Dependencies[dep])
+		}
+	}
+}
+
+func validateYarnLockfile(report *ValidationReport, pkg packageJSON, f packageFile) {
+	file, err := os.Open(f.Path)
+	if err != nil {
+		report.addError("lockfile", "Unable to read %s: %v", f.Name, err)
+		return
+	}
+	defer file.Close()
+
+	locked := map[string]bool{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// entries look like: "dep@^1.0.0", dep@~1.2.0:, "@scope/dep@git+ssh://git@host/dep.git":
+		for _, spec := range strings.Split(strings.TrimSuffix(line, ":"), ",") {
+			spec = strings.Trim(strings.TrimSpace(spec), `"`)
+			if spec == "" {
+				continue
+			}
+
+			// the name ends at the first @ after a scope's leading one
+			if at := strings.Index(spec[1:], "@"); at >= 0 {
+				locked[spec[:at+1]] = true
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		report.addError("lockfile", "Unable to read %s: %v", f.Name, err)
+		return
+	}
+
+	for _, dep := range sortedKeys(pkg.Dependencies) {
+		if !locked[dep] {
+			report.addWarning("lockfile", "%s is missing dependency %q, run yarn install to update it", f.Name, dep)
+		}
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// formatValidationReport renders the report as json or human readable text
+func formatValidationReport(report *ValidationReport, format string) ([]byte, error) {
+	switch format {
+	case "json":
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		err := enc.Encode(report)
+		return buf.Bytes(), err
+	case "", "human":
+		var lines []string
+		for _, issue := range report.Errors {
+			lines = append(lines, fmt.Sprintf("ERROR   [%s] %s", issue.Check, issue.Message))
+		}
+
+		for _, issue := range report.Warnings {
+			lines = append(lines, fmt.Sprintf("WARNING [%s] %s", issue.Check, issue.Message))
+		}
+
+		if report.Valid {
+			lines = append(lines, fmt.Sprintf("Application source is valid for %s", report.Runtime))
+		} else {
+			lines = append(lines, fmt.Sprintf("Application source failed validation with %d error(s)", len(report.Errors)))
+		}
+
+		return []byte(strings.Join(lines, "\n") + "\n"), nil
+	default:
+		return nil, fmt.Errorf("Unsupported output format: %s", format)
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(validateCmd)
+
+	validateCmd.AddCommand(validateAppCmd)
+	validateAppCmd.Flags().StringVarP(&directory, "directory", "d", "", "directory of application source archive")
+	validateAppCmd.Flags().StringVarP(&runtime, "runtime", "u", DefaultRuntime, "Runtime to use for application and optional version, ex. node[:5]")
+	validateAppCmd.Flags().StringVar(&format, "format", "", "output format: human,json")
+}
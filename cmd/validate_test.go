@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+const lockfilePackageJSON = `{
+  "name": "app",
+  "scripts": {"start": "node server.js"},
+  "dependencies": {"express": "^4.14.0", "lodash": "~4.17.0", "private": "git+ssh://git@host/private.git"}
+}`
+
+func TestValidateNodeAppLockfiles(t *testing.T) {
+	cases := []struct {
+		name     string
+		files    map[string]string
+		errors   []string
+		warnings []string
+	}{
+		{
+			name: "no lockfile",
+		},
+		{
+			name: "package-lock v2 satisfied",
+			files: map[string]string{"package-lock.json": `{"packages": {
+				"node_modules/express": {"version": "4.14.1"},
+				"node_modules/lodash": {"version": "4.17.4"},
+				"node_modules/private": {"version": "1.0.0"}}}`},
+		},
+		{
+			name: "shrinkwrap v1 out of date",
+			files: map[string]string{"npm-shrinkwrap.json": `{"dependencies": {
+				"express": {"version": "3.21.2"},
+				"lodash": {"version": "4.18.0"},
+				"private": {"version": "1.0.0"}}}`},
+			warnings: []string{
+				`npm-shrinkwrap.json locks express@3.21.2, which does not satisfy "^4.14.0" in package.json`,
+				`npm-shrinkwrap.json locks lodash@4.18.0, which does not satisfy "~4.17.0" in package.json`,
+			},
+		},
+		{
+			name:  "package-lock missing a dependency",
+			files: map[string]string{"package-lock.json": `{"dependencies": {"express": {"version": "4.14.1"}, "private": {}}}`},
+			warnings: []string{
+				`package-lock.json is missing dependency "lodash", run npm install to update it`,
+			},
+		},
+		{
+			name:   "package-lock invalid",
+			files:  map[string]string{"package-lock.json": `{"dependencies": `},
+			errors: []string{"package-lock.json is not valid JSON: unexpected end of JSON input"},
+		},
+		{
+			name: "yarn missing a dependency",
+			files: map[string]string{"yarn.lock": "# yarn lockfile v1\n\n" +
+				"\"express@^4.14.0\", express@^4.0.0:\n  version \"4.14.1\"\n\n" +
+				"\"private@git+ssh://git@host/private.git\":\n  version \"1.0.0\"\n"},
+			warnings: []string{
+				`yarn.lock is missing dependency "lodash", run yarn install to update it`,
+			},
+		},
+		{
+			name: "yarn with scoped dependencies",
+			files: map[string]string{
+				"package.json": `{"scripts": {"start": "node server.js"}, "dependencies": {"@types/node": "^6.0.0", "@scope/missing": "1"}}`,
+				"yarn.lock":    "\"@types/node@^6.0.0\":\n  version \"6.0.1\"\n",
+			},
+			warnings: []string{
+				`yarn.lock is missing dependency "@scope/missing", run yarn install to update it`,
+			},
+		},
+		{
+			name: "more than one lockfile",
+			files: map[string]string{
+				"package-lock.json": `{"dependencies": {"express": {"version": "4.14.1"}, "lodash": {"version": "4.17.4"}, "private": {}}}`,
+				"yarn.lock":         "express@^4.14.0:\nlodash@~4.17.0:\nprivate@git+ssh://git@host/private.git:\n",
+			},
+			warnings: []string{"Found more than one lockfile: package-lock.json, yarn.lock"},
+		},
+	}
+
+	for _, c := range cases {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+
+		files := map[string]string{"package.json": lockfilePackageJSON, "server.js": ""}
+		for name, content := range c.files {
+			files[name] = content
+		}
+		writeTree(t, dir, files)
+
+		packaged, _, err := listPackageFiles(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		report := validateNodeApp(packaged, "node:")
+
+		if got := issueMessages(report.Errors); !reflect.DeepEqual(got, c.errors) {
+			t.Errorf("%s: errors %q, want %q", c.name, got, c.errors)
+		}
+
+		if got := issueMessages(report.Warnings); !reflect.DeepEqual(got, c.warnings) {
+			t.Errorf("%s: warnings %q, want %q", c.name, got, c.warnings)
+		}
+
+		if report.Valid != (len(c.errors) == 0) {
+			t.Errorf("%s: valid %v with errors %q", c.name, report.Valid, c.errors)
+		}
+	}
+}
+
+func TestValidateNodeAppEngines(t *testing.T) {
+	cases := []struct {
+		engines string
+		runtime string
+		errors  []string
+	}{
+		{`{"node": ">=4.2 <7"}`, "node:6", nil},
+		{`{"node": "^4 || ^6"}`, "node:5", []string{`engines.node "^4 || ^6" does not allow the runtime node:5`}},
+		{`{"node": "4.x"}`, "node:", nil},
+	}
+
+	for _, c := range cases {
+		dir := tempDir(t)
+		defer os.RemoveAll(dir)
+
+		writeTree(t, dir, map[string]string{
+			"package.json": `{"scripts": {"start": "node index"}, "engines": ` + c.engines + `}`,
+			"index.js":     "",
+		})
+
+		packaged, _, err := listPackageFiles(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		report := validateNodeApp(packaged, c.runtime)
+		if got := issueMessages(report.Errors); !reflect.DeepEqual(got, c.errors) {
+			t.Errorf("%s on %s: errors %q, want %q", c.engines, c.runtime, got, c.errors)
+		}
+	}
+}
+
+func issueMessages(issues []ValidationIssue) []string {
+	var messages []string
+	for _, issue := range issues {
+		messages = append(messages, issue.Message)
+	}
+
+	return messages
+}
@@ -0,0 +1,191 @@
+// Copyright © 2016 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// deploymentPollInterval time between checks of a deployment being waited on
+var deploymentPollInterval = 2 * time.Second
+
+var wait bool
+var waitTimeout time.Duration
+
+// getDeploymentState retrieves the deployment as returned by Enrober, along with the response status
+func getDeploymentState(shipyardEnv string, name string) (map[string]interface{}, int, error) {
+	req, err := http.NewRequest("GET", clusterTarget+enroberPath+"/"+shipyardEnv+"/deployments/"+name, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if debug {
+		PrintDebugRequest(req)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+authToken)
+	response, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if debug {
+		PrintDebugResponse(response)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		return nil, response.StatusCode, fmt.Errorf("There was a problem retrieving %s in %s: %s", name, shipyardEnv, response.Status)
+	}
+
+	dep := map[string]interface{}{}
+	if err = json.NewDecoder(response.Body).Decode(&dep); err != nil {
+		return nil, response.StatusCode, err
+	}
+
+	return dep, response.StatusCode, nil
+}
+
+// waitForDeployment polls the deployment until it has the minimum replicas
+// available at the given revision, and exactly the given number of replicas
+// available if not negative, printing its progress as it changes
+func waitForDeployment(shipyardEnv string, name string, revision string, replicas int) error {
+	deadline := time.Now().Add(waitTimeout)
+	out := humanOutput()
+	lastProgress := ""
+	var dep map[string]interface{}
+
+	fmt.Fprintf(out, "Waiting up to %s for %s to be available\n", waitTimeout, name)
+
+	for {
+		state, status, err := getDeploymentState(shipyardEnv, name)
+		if err != nil && status != 404 { // it may not be listed straight away
+			return err
+		}
+
+		if state != nil {
+			dep = state
+
+			ready, progress := deploymentProgress(dep, revision, replicas)
+			if progress != lastProgress {
+				fmt.Fprintln(out, progress)
+				lastProgress = progress
+			}
+
+			if ready {
+				fmt.Fprintf(out, "%s is available\n", name)
+				return nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Timed out after %s waiting for %s to be available.%s", waitTimeout, name, describeConditions(dep))
+		}
+
+		time.Sleep(deploymentPollInterval)
+	}
+}
+
+// deploymentProgress reports whether the deployment is available at the revision
+// with the number of replicas, and describes how far along it is
+func deploymentProgress(dep map[string]interface{}, revision string, replicas int) (bool, string) {
+	metadata, _ := dep["metadata"].(map[string]interface{})
+	labels, _ := metadata["labels"].(map[string]interface{})
+	spec, _ := dep["spec"].(map[string]interface{})
+	status, _ := dep["status"].(map[string]interface{})
+	conditions, _ := status["conditions"].([]interface{})
+
+	current := fmt.Sprint(templateParseRevision(labels))
+	available := templateParseDeploymentStatus(conditions)
+
+	// the controller has yet to act on the latest change
+	observed := true
+	if generation, ok := metadata["generation"].(float64); ok {
+		if seen, ok := status["observedGeneration"].(float64); ok && seen < generation {
+			observed = false
+		}
+	}
+
+	// pods of the previous revision may still be serving
+	updated := true
+	if want, ok := spec["replicas"].(float64); ok {
+		if got, ok := status["updatedReplicas"].(float64); ok && got < want {
+			updated = false
+		}
+	}
+
+	// scaled to exactly the requested replicas
+	scaled := true
+	if replicas >= 0 {
+		want, _ := numberOr(spec["replicas"], -1).(int)
+		got, _ := numberOr(status["availableReplicas"], 0).(int)
+		scaled = want == replicas && got == replicas
+	}
+
+	ready := available && observed && updated && scaled && (revision == "" || current == revision)
+
+	progress := fmt.Sprintf("revision %s, %v/%v replicas available, MinimumReplicasAvailable=%t",
+		current, numberOr(status["availableReplicas"], 0), numberOr(spec["replicas"], "?"), available)
+
+	return ready, progress
+}
+
+func numberOr(v interface{}, fallback interface{}) interface{} {
+	if n, ok := v.(float64); ok {
+		return int(n)
+	}
+
+	return fallback
+}
+
+// describeConditions lists the status conditions of the deployment, for explaining a timeout
+func describeConditions(dep map[string]interface{}) string {
+	status, _ := dep["status"].(map[string]interface{})
+	conditions, _ := status["conditions"].([]interface{})
+	if len(conditions) == 0 {
+		return ""
+	}
+
+	lines := []string{"\nLast conditions:"}
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		lines = append(lines, fmt.Sprintf("  %v=%v (%v) %v", cond["type"], cond["status"], cond["reason"], cond["message"]))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// waitOrExit waits for the deployment when --wait was given and the change was
+// accepted, exiting with an error if it doesn't become available in time
+func waitOrExit(status int, shipyardEnv string, name string, revision string, replicas int) {
+	if !wait || (status != 200 && status != 201) {
+		return
+	}
+
+	if err := waitForDeployment(shipyardEnv, name, revision, replicas); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
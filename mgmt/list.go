@@ -11,7 +11,7 @@ import (
 type ProxyList []string
 
 // ListProxies lsits the proxy in an org
-func ListProxies(target string, org string, token string) (list ProxyList, err error) {
+func ListProxies(client *http.Client, target string, org string, token string) (list ProxyList, err error) {
   url := fmt.Sprintf("%s/v1/o/%s/apis", target, org)
 
   req, err := http.NewRequest("GET", url, nil)
@@ -19,7 +19,7 @@ func ListProxies(target string, org string, token string) (list ProxyList, err e
 
   req.Header.Set("Authorization", "Bearer " + token)
 
-  resp, err := http.DefaultClient.Do(req)
+  resp, err := client.Do(req)
   if err != nil { return nil, err }
 
   data, err := ioutil.ReadAll(resp.Body)
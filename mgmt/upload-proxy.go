@@ -7,9 +7,14 @@ import (
 	"os"
 )
 
+// ProxyImportURL the URL a zipped proxy bundle is uploaded to
+func ProxyImportURL(target string, org string, name string) string {
+	return fmt.Sprintf("%s/v1/o/%s/apis?action=import&validate=fales&name=%s", target, org, name)
+}
+
 // UploadProxyBundle uploads a zipped proxy bundle
-func UploadProxyBundle(target string, org string, env string, token string, bundlePath string, name string, debug bool) error {
-	url := fmt.Sprintf("%s/v1/o/%s/apis?action=import&validate=fales&name=%s", target, org, name)
+func UploadProxyBundle(client *http.Client, target string, org string, env string, token string, bundlePath string, name string, debug bool) error {
+	url := ProxyImportURL(target, org, name)
 
 	zip, err := os.Open(bundlePath)
 	if err != nil {
@@ -23,7 +28,7 @@ func UploadProxyBundle(target string, org string, env string, token string, bund
 
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return err
 	}
@@ -30,6 +30,13 @@ const (
   ShipyardctlConfigFileName = "config"
   // Default target for proxy Management API
   DefaultMgmtApi = "https://api.enterprise.apigee.com"
+
+  // ConflictSkip keeps the existing context when importing one of the same name
+  ConflictSkip = "skip"
+  // ConflictRename imports a context under a new, numbered name
+  ConflictRename = "rename"
+  // ConflictOverwrite replaces the existing context's cluster info
+  ConflictOverwrite = "overwrite"
 )
 
 // InitNewConfigFile creates a new config file
@@ -65,9 +72,9 @@ func InitNewConfigFile(name string, sso string, clusterTarget string) error {
 // MakeConfig creates a context named default based on the given environment
 func MakeConfig(name string, sso string, clusterTarget string) *Config {
   cluster := Cluster{name, clusterTarget, sso}
-  context := Context{name, cluster, User{}, DefaultMgmtApi}
+  context := Context{name, cluster, User{}, DefaultMgmtApi, ""}
 
-  return &Config{name, []Context{context}}
+  return &Config{CurrentContext: name, Contexts: []Context{context}}
 }
 
 // GetCurrentToken retrieves the user token from the current active context
@@ -116,6 +123,12 @@ func (c *Config) Save() error {
     return err
   }
 
+  // the config may not have been written out before
+  err = os.MkdirAll(filepath.Dir(path), 0755)
+  if err != nil {
+    return err
+  }
+
   return ioutil.WriteFile(path, data, 0755)
 }
 
@@ -143,12 +156,43 @@ func (c *Config) GetCurrentMgmtAPITarget() string {
   return context.ProxyMgmtApi
 }
 
+// GetCurrentMaxArchiveSize retrieves the largest app archive the current context
+// allows importing in bytes, 0 if there is no limit
+func (c *Config) GetCurrentMaxArchiveSize() (int64, error) {
+  context := c.GetCurrentContext()
+  if context == nil {
+    return 0, nil
+  }
+
+  return ParseSize(context.MaxArchiveSize)
+}
+
 // NewContext used to create a new context
-func (c *Config) NewContext(name string, sso string, clusterTarget string, mgmtTarget string) error {
-  c.Contexts = append(c.Contexts, Context{name, Cluster{name, clusterTarget, sso}, User{}, mgmtTarget})
-  c.Save()
+func (c *Config) NewContext(name string, sso string, clusterTarget string, mgmtTarget string, maxArchiveSize string) error {
+  if _, err := ParseSize(maxArchiveSize); err != nil {
+    return err
+  }
 
-  return nil
+  c.Contexts = append(c.Contexts, Context{name, Cluster{name, clusterTarget, sso}, User{}, mgmtTarget, maxArchiveSize})
+
+  return c.Save()
+}
+
+// SetMaxArchiveSize changes the largest app archive the named context allows
+// importing, an empty size removes the limit
+func (c *Config) SetMaxArchiveSize(name string, maxArchiveSize string) error {
+  if _, err := ParseSize(maxArchiveSize); err != nil {
+    return err
+  }
+
+  for ndx, con := range c.Contexts {
+    if con.Name == name {
+      c.Contexts[ndx].MaxArchiveSize = maxArchiveSize
+      return c.Save()
+    }
+  }
+
+  return fmt.Errorf("Invalid context name: %s", name)
 }
 
 // DumpConfig dumps the config to stdout
@@ -175,3 +219,93 @@ func (c *Config) SaveToken(username string, token string) error {
 
   return fmt.Errorf("Could not find current context: %s", c.CurrentContext)
 }
+
+// ExportContexts marshals the named contexts without any user credentials
+func (c *Config) ExportContexts(names []string) ([]byte, error) {
+  var contexts []Context
+
+  for _, name := range names {
+    context := c.getContext(name)
+    if context == nil {
+      return nil, fmt.Errorf("Invalid context name: %s", name)
+    }
+
+    context.UserInfo = User{} // never share credentials
+    contexts = append(contexts, *context)
+  }
+
+  return yaml.Marshal(sharedContexts{contexts})
+}
+
+// ImportContexts merges the contexts of an exported snippet into the config,
+// resolving name conflicts with the given strategy. It returns a line of output per context.
+func (c *Config) ImportContexts(data []byte, strategy string) ([]string, error) {
+  switch strategy {
+  case ConflictSkip, ConflictRename, ConflictOverwrite:
+  default:
+    return nil, fmt.Errorf("Invalid conflict strategy: %s", strategy)
+  }
+
+  shared := sharedContexts{}
+  err := yaml.Unmarshal(data, &shared)
+  if err != nil {
+    return nil, err
+  }
+
+  if len(shared.Contexts) == 0 {
+    return nil, fmt.Errorf("No contexts found to import")
+  }
+
+  // validate everything before changing the loaded config
+  for _, context := range shared.Contexts {
+    if context.Name == "" {
+      return nil, fmt.Errorf("Found a context without a name")
+    }
+  }
+
+  var results []string
+  for _, context := range shared.Contexts {
+    context.UserInfo = User{} // never take credentials from a snippet
+    existing := c.getContext(context.Name)
+
+    if existing == nil {
+      c.Contexts = append(c.Contexts, context)
+      results = append(results, fmt.Sprintf("Added context %s", context.Name))
+      continue
+    }
+
+    switch strategy {
+    case ConflictSkip:
+      results = append(results, fmt.Sprintf("Skipped context %s, it already exists", context.Name))
+    case ConflictRename:
+      original := context.Name
+      for i := 1; c.getContext(context.Name) != nil; i++ {
+        context.Name = fmt.Sprintf("%s-%d", original, i)
+      }
+      context.ClusterInfo.Name = context.Name
+
+      c.Contexts = append(c.Contexts, context)
+      results = append(results, fmt.Sprintf("Added context %s as %s", original, context.Name))
+    case ConflictOverwrite:
+      for ndx, con := range c.Contexts {
+        if con.Name == context.Name {
+          context.UserInfo = con.UserInfo // keep the local credentials
+          c.Contexts[ndx] = context
+        }
+      }
+      results = append(results, fmt.Sprintf("Overwrote context %s", context.Name))
+    }
+  }
+
+  return results, c.Save()
+}
+
+func (c *Config) getContext(name string) *Context {
+  for _, con := range c.Contexts {
+    if con.Name == name {
+      return &con
+    }
+  }
+
+  return nil
+}
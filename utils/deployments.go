@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// DeploymentSpec desired state of a single deployment
+type DeploymentSpec struct {
+	Name        string            `yaml:"name"`
+	Revision    int               `yaml:"revision"`
+	Replicas    *int              `yaml:"replicas"` // left as it is when not given
+	EnvVars     map[string]string `yaml:"envVars"`
+	EdgeConfigs map[string]string `yaml:"edgeConfigs"` // NAME: config:key
+}
+
+// DeploymentsFile desired deployments, listed per "org:env"
+type DeploymentsFile struct {
+	Environments map[string][]DeploymentSpec `yaml:"environments"`
+}
+
+// LoadDeploymentsFile reads and validates a file of desired deployments
+func LoadDeploymentsFile(path string) (*DeploymentsFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file := DeploymentsFile{}
+	if err = yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	if len(file.Environments) == 0 {
+		return nil, fmt.Errorf("%s: no environments are listed", path)
+	}
+
+	for env, specs := range file.Environments {
+		if split := strings.SplitN(env, ":", 2); len(split) != 2 || split[0] == "" || split[1] == "" {
+			return nil, fmt.Errorf("%s: environment %q must be given as org:env", path, env)
+		}
+
+		names := map[string]bool{}
+		for _, spec := range specs {
+			if spec.Name == "" {
+				return nil, fmt.Errorf("%s: a deployment in %s is missing its name", path, env)
+			}
+
+			if names[spec.Name] {
+				return nil, fmt.Errorf("%s: %s is listed more than once in %s", path, spec.Name, env)
+			}
+			names[spec.Name] = true
+
+			if spec.Revision < 1 {
+				return nil, fmt.Errorf("%s: %s in %s needs a revision of at least 1", path, spec.Name, env)
+			}
+
+			if spec.Replicas != nil && *spec.Replicas < 0 {
+				return nil, fmt.Errorf("%s: %s in %s cannot have negative replicas", path, spec.Name, env)
+			}
+
+			for name, ref := range spec.EdgeConfigs {
+				if split := strings.SplitN(ref, ":", 2); len(split) != 2 || split[0] == "" || split[1] == "" {
+					return nil, fmt.Errorf("%s: edge config %s of %s in %s must be given as config:key", path, name, spec.Name, env)
+				}
+			}
+		}
+	}
+
+	return &file, nil
+}
+
+// EnvironmentNames retrieves the sorted "org:env" names of the file
+func (f *DeploymentsFile) EnvironmentNames() []string {
+	var envs []string
+	for env := range f.Environments {
+		envs = append(envs, env)
+	}
+
+	sort.Strings(envs)
+	return envs
+}
+
+// EnvVarPairs retrieves the env vars of the deployment as sorted "NAME=VAL" pairs
+func (s DeploymentSpec) EnvVarPairs() []string {
+	return sortedPairs(s.EnvVars)
+}
+
+// EdgeConfigPairs retrieves the edge config refs of the deployment as sorted "NAME=config:key" pairs
+func (s DeploymentSpec) EdgeConfigPairs() []string {
+	return sortedPairs(s.EdgeConfigs)
+}
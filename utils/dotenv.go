@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+var dotenvNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// LoadDotenv reads the variables in a dotenv file as "NAME=VAL" pairs, in file order
+func LoadDotenv(path string) ([]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs, err := ParseDotenv(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return pairs, nil
+}
+
+// ParseDotenv parses dotenv syntax into "NAME=VAL" pairs:
+//
+//	# comments and blank lines are skipped
+//	export NAME=value     # trailing comments are dropped from unquoted values
+//	NAME='literal value, may span lines'
+//	NAME="supports \n, \t, \", \\ and \$ escapes, may span lines"
+//
+// Later definitions of a name replace earlier ones.
+func ParseDotenv(data string) ([]string, error) {
+	var pairs []string
+	index := map[string]int{}
+
+	data = strings.Replace(data, "\r\n", "\n", -1)
+	line := 1
+
+	for len(data) > 0 {
+		start := line
+
+		var current string
+		current, data = nextLine(data)
+		line++
+
+		current = strings.TrimSpace(current)
+		if current == "" || strings.HasPrefix(current, "#") {
+			continue
+		}
+
+		current = strings.TrimPrefix(current, "export ")
+
+		eq := strings.Index(current, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("line %d: expected NAME=VALUE", start)
+		}
+
+		name := strings.TrimSpace(current[:eq])
+		if !dotenvNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", start, name)
+		}
+
+		value := strings.TrimLeft(current[eq+1:], " \t")
+
+		var err error
+		if strings.HasPrefix(value, `"`) || strings.HasPrefix(value, "'") {
+			// quoted values may continue over the following lines
+			var consumed int
+			value, consumed, err = parseQuoted(value, data)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", start, err)
+			}
+
+			for _, c := range data[:consumed] {
+				if c == '\n' {
+					line++
+				}
+			}
+
+			data = data[consumed:]
+		} else {
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = value[:i]
+			}
+
+			value = strings.TrimSpace(value)
+		}
+
+		pair := name + "=" + value
+		if i, ok := index[name]; ok {
+			pairs[i] = pair
+		} else {
+			index[name] = len(pairs)
+			pairs = append(pairs, pair)
+		}
+	}
+
+	return pairs, nil
+}
+
+func nextLine(data string) (string, string) {
+	if i := strings.IndexByte(data, '\n'); i >= 0 {
+		return data[:i], data[i+1:]
+	}
+
+	return data, ""
+}
+
+// parseQuoted reads the quoted value starting the given line, continuing into rest
+// when the closing quote is on a later line. It returns the unquoted value and how
+// much of rest was consumed.
+func parseQuoted(value string, rest string) (string, int, error) {
+	quote := value[0]
+	text := value[1:] + "\n" + rest
+	var buf bytes.Buffer
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+
+		switch {
+		case c == quote:
+			trailing := strings.TrimSpace(lineRemainder(text[i+1:]))
+			if trailing != "" && !strings.HasPrefix(trailing, "#") {
+				return "", 0, fmt.Errorf("unexpected characters after closing quote: %s", trailing)
+			}
+
+			// consume the rest of the closing line from the remaining data
+			consumed := i + 1 - len(value)
+			if consumed < 0 {
+				consumed = 0
+			} else if j := strings.IndexByte(rest[consumed:], '\n'); j >= 0 {
+				consumed += j + 1
+			} else {
+				consumed = len(rest)
+			}
+
+			return buf.String(), consumed, nil
+		case c == '\\' && quote == '"' && i+1 < len(text):
+			i++
+			switch text[i] {
+			case 'n':
+				buf.WriteByte('\n')
+			case 'r':
+				buf.WriteByte('\r')
+			case 't':
+				buf.WriteByte('\t')
+			case '"', '\\', '$':
+				buf.WriteByte(text[i])
+			default:
+				buf.WriteByte('\\')
+				buf.WriteByte(text[i])
+			}
+		default:
+			buf.WriteByte(c)
+		}
+	}
+
+	return "", 0, fmt.Errorf("missing closing %c", quote)
+}
+
+func lineRemainder(s string) string {
+	line, _ := nextLine(s)
+	return line
+}
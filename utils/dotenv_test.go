@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"empty", "", nil},
+		{"comments and blank lines", "# comment\n\n  # indented\nA=1\n", []string{"A=1"}},
+		{"export", "export A=1\nexport  B=2\n", []string{"A=1", "B=2"}},
+		{"spaces around", "  A = 1  \n", []string{"A=1"}},
+		{"empty value", "A=\nB=''\nC=\"\"\n", []string{"A=", "B=", "C="}},
+		{"trailing comment", "A=1 # one\nB=a#b\n", []string{"A=1", "B=a#b"}},
+		{"equals in value", "A=b=c\n", []string{"A=b=c"}},
+		{"commas", "A=a,b,c\n", []string{"A=a,b,c"}},
+		{"crlf", "A=1\r\nB=2\r\n", []string{"A=1", "B=2"}},
+		{"no trailing newline", "A=1", []string{"A=1"}},
+		{"later definitions replace", "A=1\nB=2\nA=3\n", []string{"A=3", "B=2"}},
+		{"dotted names", "app.log-level=debug\n", []string{"app.log-level=debug"}},
+
+		{"single quoted", "A='a # b'\n", []string{"A=a # b"}},
+		{"single quoted escapes are literal", `A='a\nb\'`, []string{`A=a\nb\`}},
+		{"double quoted", "A=\"a # b\" # comment\n", []string{"A=a # b"}},
+		{"double quoted escapes", `A="a\nb\tc\"d\\e\$f\xg\r"`, []string{"A=a\nb\tc\"d\\e$f\\xg\r"}},
+
+		{"multiline double quoted", "A=\"line 1\nline 2\"\nB=2\n", []string{"A=line 1\nline 2", "B=2"}},
+		{"multiline single quoted", "A='-----BEGIN KEY-----\nabc\n-----END KEY-----'\nB=2", []string{"A=-----BEGIN KEY-----\nabc\n-----END KEY-----", "B=2"}},
+		{"multiline with blank lines and comments", "A=\"1\n\n# not a comment\n\"\n", []string{"A=1\n\n# not a comment\n"}},
+		{"quote of the other kind", "A=\"it's\"\nB='say \"hi\"'\n", []string{"A=it's", `B=say "hi"`}},
+	}
+
+	for _, c := range cases {
+		got, err := ParseDotenv(c.in)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestParseDotenvErrors(t *testing.T) {
+	cases := []struct {
+		in  string
+		err string
+	}{
+		{"A=1\nNOVALUE\n", "line 2: expected NAME=VALUE"},
+		{"1A=1\n", `line 1: invalid variable name "1A"`},
+		{"A B=1\n", `line 1: invalid variable name "A B"`},
+		{"=1\n", `line 1: invalid variable name ""`},
+		{"A=\"open\nB=2\n", `line 1: missing closing "`},
+		{"A='open\n", "line 1: missing closing '"},
+		{"A=\"1\"x\n", "line 1: unexpected characters after closing quote: x"},
+		{"A=\"1\n2\"\nB=2\nC\n", "line 4: expected NAME=VALUE"},
+	}
+
+	for _, c := range cases {
+		_, err := ParseDotenv(c.in)
+		if err == nil || err.Error() != c.err {
+			t.Errorf("ParseDotenv(%q) error %v, want %q", c.in, err, c.err)
+		}
+	}
+}
@@ -74,4 +74,16 @@ func getConfigPath() (string, error) {
   }
 
   return filepath.Join(home, ShipyardctlConfigDir, ShipyardctlConfigFileName), err
-}
\ No newline at end of file
+}
+
+// ConfigDir retrieves the directory holding the config file and other shipyardctl state,
+// creating it if necessary
+func ConfigDir() (string, error) {
+  home, err := homedir()
+  if err != nil {
+    return "", err
+  }
+
+  dir := filepath.Join(home, ShipyardctlConfigDir)
+  return dir, os.MkdirAll(dir, 0755)
+}
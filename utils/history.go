@@ -0,0 +1,257 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	// HistoryDirName name of the directory, within the config directory, holding saved build and deploy output
+	HistoryDirName = "history"
+	// HistoryBuilds kind of history entry saved for each application import build
+	HistoryBuilds = "builds"
+	// HistoryDeployments kind of history entry saved for each deployment change
+	HistoryDeployments = "deployments"
+
+	// DefaultHistoryMaxEntries number of entries kept per application and kind, when not configured
+	DefaultHistoryMaxEntries = 50
+	// DefaultHistoryMaxAgeDays age after which entries are removed, when not configured
+	DefaultHistoryMaxAgeDays = 90
+
+	historyIndexFileName = "index"
+)
+
+// HistoryRetention limits on the saved history of each application
+type HistoryRetention struct {
+	MaxEntries int `yaml:"maxEntries,omitempty"`
+	MaxAgeDays int `yaml:"maxAgeDays,omitempty"`
+}
+
+// HistoryEntry a saved build stream or deploy response
+type HistoryEntry struct {
+	ID        string    `json:"id" yaml:"id"`
+	Kind      string    `json:"kind" yaml:"kind"`
+	Org       string    `json:"org" yaml:"org"`
+	App       string    `json:"app" yaml:"app"`
+	Env       string    `json:"env,omitempty" yaml:"env,omitempty"`
+	Revision  string    `json:"revision,omitempty" yaml:"revision,omitempty"`
+	Time      time.Time `json:"time" yaml:"time"`
+	Succeeded bool      `json:"succeeded" yaml:"succeeded"`
+
+	// deployment changes only
+	Action     string            `json:"action,omitempty" yaml:"action,omitempty"`
+	User       string            `json:"user,omitempty" yaml:"user,omitempty"`
+	EnvChanges []string          `json:"envChanges,omitempty" yaml:"envChanges,omitempty"`
+	EnvVars    map[string]string `json:"-" yaml:"envVars,omitempty"` // digests of the values, for diffing the next change
+}
+
+// GetHistoryRetention retrieves the configured history limits, filling in the defaults
+func (c *Config) GetHistoryRetention() HistoryRetention {
+	r := HistoryRetention{}
+	if c != nil && c.History != nil {
+		r = *c.History
+	}
+
+	if r.MaxEntries <= 0 {
+		r.MaxEntries = DefaultHistoryMaxEntries
+	}
+
+	if r.MaxAgeDays <= 0 {
+		r.MaxAgeDays = DefaultHistoryMaxAgeDays
+	}
+
+	return r
+}
+
+// CreateHistoryLog starts a new history entry, returning it along with the
+// file its output should be written to
+func CreateHistoryLog(kind string, org string, app string) (*HistoryEntry, *os.File, error) {
+	dir, err := historyAppDir(kind, org, app)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now().UTC()
+	entry := &HistoryEntry{
+		ID:   now.Format("20060102T150405.000Z"),
+		Kind: kind,
+		Org:  org,
+		App:  app,
+		Time: now,
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, entry.ID+".log"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return entry, file, nil
+}
+
+// SaveHistoryEntry adds the entry to its application's history index, then
+// removes the entries beyond the retention limits
+func SaveHistoryEntry(entry *HistoryEntry, retention HistoryRetention) error {
+	dir, err := historyAppDir(entry.Kind, entry.Org, entry.App)
+	if err != nil {
+		return err
+	}
+
+	entries, err := loadHistoryIndex(dir)
+	if err != nil {
+		return err
+	}
+
+	entries = append(entries, *entry)
+	sort.Sort(byHistoryTime(entries))
+
+	cutoff := time.Now().AddDate(0, 0, -retention.MaxAgeDays)
+	var kept []HistoryEntry
+
+	for i, e := range entries {
+		if len(entries)-i > retention.MaxEntries || e.Time.Before(cutoff) {
+			os.Remove(filepath.Join(dir, e.ID+".log"))
+			continue
+		}
+
+		kept = append(kept, e)
+	}
+
+	data, err := yaml.Marshal(kept)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, historyIndexFileName), data, 0600)
+}
+
+// ListHistory retrieves the saved entries of the given kind, oldest first.
+// An empty org or app matches all of them.
+func ListHistory(kind string, org string, app string) ([]HistoryEntry, error) {
+	if org == "" {
+		org = "*"
+	}
+
+	if app == "" {
+		app = "*"
+	}
+
+	dir, err := historyAppDir(kind, org, app)
+	if err != nil {
+		return nil, err
+	}
+
+	dirs, err := filepath.Glob(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []HistoryEntry
+	for _, d := range dirs {
+		index, err := loadHistoryIndex(d)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, index...)
+	}
+
+	sort.Sort(byHistoryTime(entries))
+	return entries, nil
+}
+
+// ReadHistoryLog retrieves the output saved with the entry
+func ReadHistoryLog(entry HistoryEntry) ([]byte, error) {
+	dir, err := historyAppDir(entry.Kind, entry.Org, entry.App)
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.ReadFile(filepath.Join(dir, entry.ID+".log"))
+}
+
+// DiffEnvDigests lists the variables added (+NAME), removed (-NAME) and
+// changed (~NAME) between two sets of value digests, by name
+func DiffEnvDigests(before map[string]string, after map[string]string) []string {
+	var changes []string
+	for name, digest := range after {
+		if old, ok := before[name]; !ok {
+			changes = append(changes, "+"+name)
+		} else if old != digest {
+			changes = append(changes, "~"+name)
+		}
+	}
+
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			changes = append(changes, "-"+name)
+		}
+	}
+
+	sort.Sort(byChangeName(changes))
+	return changes
+}
+
+func historyAppDir(kind string, org string, app string) (string, error) {
+	home, err := homedir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ShipyardctlConfigDir, HistoryDirName, kind, historyPathName(org), historyPathName(app)), nil
+}
+
+// historyPathName keeps names from escaping the history directory
+func historyPathName(name string) string {
+	if name == "*" {
+		return name
+	}
+
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == os.PathSeparator {
+			return '_'
+		}
+
+		return r
+	}, name)
+
+	if name == "" || name == "." || name == ".." {
+		return fmt.Sprintf("_%s_", name)
+	}
+
+	return name
+}
+
+func loadHistoryIndex(dir string) ([]HistoryEntry, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, historyIndexFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var entries []HistoryEntry
+	return entries, yaml.Unmarshal(data, &entries)
+}
+
+type byHistoryTime []HistoryEntry
+
+func (h byHistoryTime) Len() int           { return len(h) }
+func (h byHistoryTime) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h byHistoryTime) Less(i, j int) bool { return h[i].Time.Before(h[j].Time) }
+
+type byChangeName []string
+
+func (c byChangeName) Len() int           { return len(c) }
+func (c byChangeName) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+func (c byChangeName) Less(i, j int) bool { return c[i][1:] < c[j][1:] }
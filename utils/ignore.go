@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFileName name of the file listing paths to leave out of an app import
+const IgnoreFileName = ".shipyardignore"
+
+// DefaultIgnorePatterns paths left out of every app import, unless negated in the ignore file
+var DefaultIgnorePatterns = []string{
+	".git/",
+	".svn/",
+	".hg/",
+	"node_modules/",
+	"coverage/",
+	".nyc_output/",
+	".env",
+	".env.*",
+	".npmrc", // may hold registry auth tokens
+	"npm-debug.log*",
+	".DS_Store",
+	IgnoreFileName,
+}
+
+type ignoreRule struct {
+	pattern string
+	regex   *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// IgnoreMatcher matches slash separated paths against gitignore style patterns
+type IgnoreMatcher struct {
+	rules []ignoreRule
+}
+
+// NewIgnoreMatcher compiles the given gitignore style patterns, later patterns take precedence
+func NewIgnoreMatcher(patterns []string) *IgnoreMatcher {
+	m := &IgnoreMatcher{}
+
+	for _, p := range patterns {
+		if rule, ok := parseIgnoreRule(p); ok {
+			m.rules = append(m.rules, rule)
+		}
+	}
+
+	return m
+}
+
+// LoadIgnoreMatcher builds a matcher from the default patterns and the
+// ignore file in the given directory, if there is one
+func LoadIgnoreMatcher(dir string) (*IgnoreMatcher, error) {
+	patterns := append([]string{}, DefaultIgnorePatterns...)
+
+	file, err := os.Open(filepath.Join(dir, IgnoreFileName))
+	if os.IsNotExist(err) {
+		return NewIgnoreMatcher(patterns), nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return NewIgnoreMatcher(patterns), nil
+}
+
+// Match reports whether the given path, relative to the matcher's root, is ignored
+// along with the pattern deciding it
+func (m *IgnoreMatcher) Match(path string, isDir bool) (bool, string) {
+	ignored := false
+	decidedBy := ""
+
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+
+		if rule.regex.MatchString(path) {
+			ignored = !rule.negate
+			decidedBy = rule.pattern
+		}
+	}
+
+	return ignored, decidedBy
+}
+
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{pattern: line}
+	p := line
+
+	if strings.HasPrefix(p, "!") {
+		rule.negate = true
+		p = p[1:]
+	} else if strings.HasPrefix(p, `\`) { // escaped leading "!" or "#"
+		p = p[1:]
+	}
+
+	if strings.HasSuffix(p, "/") {
+		rule.dirOnly = true
+		p = strings.TrimSuffix(p, "/")
+	}
+
+	// patterns with a slash are relative to the root, others match at any depth
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+	if p == "" {
+		return ignoreRule{}, false
+	}
+
+	expr := globToRegexp(p)
+	if anchored {
+		expr = "^" + expr + "$"
+	} else {
+		expr = "^(?:.*/)?" + expr + "$"
+	}
+
+	regex, err := regexp.Compile(expr)
+	if err != nil {
+		return ignoreRule{}, false
+	}
+
+	rule.regex = regex
+	return rule, true
+}
+
+func globToRegexp(glob string) string {
+	var expr bytes.Buffer
+
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			expr.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				expr.WriteString(`\[`)
+				continue
+			}
+
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+
+			expr.WriteString("[" + strings.Replace(class, `\`, `\\`, -1) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			expr.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	return expr.String()
+}
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		isDir    bool
+		ignored  bool
+	}{
+		{"plain name at the root", []string{"secret.txt"}, "secret.txt", false, true},
+		{"plain name at any depth", []string{"secret.txt"}, "a/b/secret.txt", false, true},
+		{"plain name not a prefix", []string{"secret.txt"}, "secret.txt.bak", false, false},
+		{"star within a segment", []string{"*.log"}, "logs/app.log", false, true},
+		{"star does not cross slashes", []string{"logs/*.log"}, "logs/a/app.log", false, false},
+		{"question mark", []string{"file?.js"}, "file1.js", false, true},
+		{"character class", []string{"file[0-9].js"}, "filea.js", false, false},
+		{"negated character class", []string{"file[!0-9].js"}, "filea.js", false, true},
+		{"anchored to the root", []string{"/build"}, "build", true, true},
+		{"anchored not nested", []string{"/build"}, "src/build", true, false},
+		{"inner slash anchors", []string{"src/gen"}, "lib/src/gen", true, false},
+		{"dir only matches dirs", []string{"tmp/"}, "tmp", true, true},
+		{"dir only skips files", []string{"tmp/"}, "tmp", false, false},
+		{"dir only at any depth", []string{"tmp/"}, "a/tmp", true, true},
+		{"leading double star", []string{"**/fixtures"}, "test/unit/fixtures", true, true},
+		{"leading double star at the root", []string{"**/fixtures"}, "fixtures", true, true},
+		{"trailing double star", []string{"docs/**"}, "docs/a/b.md", false, true},
+		{"trailing double star not the dir", []string{"docs/**"}, "docs", true, false},
+		{"inner double star", []string{"a/**/z.js"}, "a/b/c/z.js", false, true},
+		{"inner double star no dirs", []string{"a/**/z.js"}, "a/z.js", false, true},
+		{"negation re-includes", []string{"*.log", "!keep.log"}, "keep.log", false, false},
+		{"later pattern wins", []string{"!keep.log", "*.log"}, "keep.log", false, true},
+		{"comment ignored", []string{"# secret.txt"}, "secret.txt", false, false},
+		{"escaped hash", []string{`\#notes`}, "#notes", false, true},
+		{"escaped bang", []string{`\!important`}, "!important", false, true},
+		{"trailing spaces trimmed", []string{"secret.txt  "}, "secret.txt", false, true},
+		{"blank line ignored", []string{""}, "anything", false, false},
+	}
+
+	for _, tt := range tests {
+		ignored, _ := NewIgnoreMatcher(tt.patterns).Match(tt.path, tt.isDir)
+		if ignored != tt.ignored {
+			t.Errorf("%s: Match(%q) with %q = %v, want %v", tt.name, tt.path, tt.patterns, ignored, tt.ignored)
+		}
+	}
+}
+
+func TestIgnoreMatcherDecidedBy(t *testing.T) {
+	m := NewIgnoreMatcher([]string{"*.log", "!keep.log"})
+
+	if _, pattern := m.Match("keep.log", false); pattern != "!keep.log" {
+		t.Errorf("decided by %q, want %q", pattern, "!keep.log")
+	}
+
+	if _, pattern := m.Match("app.js", false); pattern != "" {
+		t.Errorf("decided by %q, want none", pattern)
+	}
+}
+
+func TestDefaultIgnorePatterns(t *testing.T) {
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{".git", true, true},
+		{"node_modules", true, true},
+		{"lib/node_modules", true, true},
+		{"node_modules", false, false}, // a file of that name
+		{"coverage", true, true},
+		{".env", false, true},
+		{".env.production", false, true},
+		{"config/.env", false, true},
+		{"environment.js", false, false},
+		{"npm-debug.log.1234", false, true},
+		{".DS_Store", false, true},
+		{".npmrc", false, true},
+		{IgnoreFileName, false, true},
+		{"index.js", false, false},
+		{"package.json", false, false},
+	}
+
+	m := NewIgnoreMatcher(DefaultIgnorePatterns)
+	for _, tt := range tests {
+		if ignored, _ := m.Match(tt.path, tt.isDir); ignored != tt.ignored {
+			t.Errorf("Match(%q, %v) = %v, want %v", tt.path, tt.isDir, ignored, tt.ignored)
+		}
+	}
+}
+
+func TestLoadIgnoreMatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ignore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ignoreFile := "# ship the vendored modules\n!node_modules/\n*.tmp\n"
+	if err = ioutil.WriteFile(filepath.Join(dir, IgnoreFileName), []byte(ignoreFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := LoadIgnoreMatcher(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ignored, _ := m.Match("node_modules", true); ignored {
+		t.Error("node_modules should be re-included by the ignore file")
+	}
+
+	if ignored, _ := m.Match("a.tmp", false); !ignored {
+		t.Error("a.tmp should be ignored by the ignore file")
+	}
+
+	if ignored, _ := m.Match(".git", true); !ignored {
+		t.Error(".git should still be ignored by default")
+	}
+}
@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ManifestFileName name of the project manifest file searched for by shipyardctl
+const ManifestFileName = "shipyard.yaml"
+
+// Proxy representation of the Edge proxy paths of an app
+type Proxy struct {
+	BasePath   string `yaml:"basePath"`
+	TargetPath string `yaml:"targetPath"`
+}
+
+// ManifestEnvironment per-environment deployment settings of an app
+type ManifestEnvironment struct {
+	EnvVars     map[string]string `yaml:"envVars"`
+	EdgeConfigs map[string]string `yaml:"edgeConfigs"`
+	Replicas    int               `yaml:"replicas"`
+}
+
+// Manifest project level description of an app
+type Manifest struct {
+	Name         string                         `yaml:"name"`
+	Runtime      string                         `yaml:"runtime"`
+	Directory    string                         `yaml:"directory"`
+	Install      string                         `yaml:"install"` // "auto", or the command installing production dependencies
+	Replicas     int                            `yaml:"replicas"`
+	EnvVars      map[string]string              `yaml:"envVars"`
+	Proxy        Proxy                          `yaml:"proxy"`
+	Environments map[string]ManifestEnvironment `yaml:"environments"`
+
+	// Apps directories, or globs of them, of the apps in a repository holding several
+	Apps []string `yaml:"apps"`
+
+	// Path location of the manifest file it was loaded from
+	Path string `yaml:"-"`
+}
+
+// FindManifest walks from the given directory upward looking for a project manifest.
+// An empty path is returned if none was found.
+func FindManifest(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		path := filepath.Join(dir, ManifestFileName)
+		found, err := exists(path)
+		if err != nil {
+			return "", err
+		}
+
+		if found {
+			return path, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir { // reached the filesystem root
+			return "", nil
+		}
+
+		dir = parent
+	}
+}
+
+// LoadManifest reads the project manifest at the given path
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := Manifest{}
+	err = yaml.Unmarshal(data, &manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	for env, e := range manifest.Environments {
+		for name, ref := range e.EdgeConfigs {
+			if split := strings.SplitN(ref, ":", 2); len(split) != 2 || split[0] == "" || split[1] == "" {
+				return nil, fmt.Errorf("edge config %s of environment %s must be given as config:key", name, env)
+			}
+		}
+	}
+
+	manifest.Path, err = filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &manifest, nil
+}
+
+// SourceDir resolves the app source directory relative to the manifest location
+func (m *Manifest) SourceDir() string {
+	dir := m.Directory
+	if dir == "" {
+		dir = "."
+	}
+
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+
+	return filepath.Join(filepath.Dir(m.Path), dir)
+}
+
+// AppDirs resolves the app directories relative to the manifest location
+func (m *Manifest) AppDirs() ([]string, error) {
+	return ResolveAppDirs(filepath.Dir(m.Path), m.Apps)
+}
+
+// ResolveAppDirs expands directories and globs of them, relative to base, into the
+// sorted list of app directories. Glob matches without a package.json are skipped.
+func ResolveAppDirs(base string, patterns []string) ([]string, error) {
+	var dirs []string
+	seen := map[string]bool{}
+
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(base, pattern)
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid app directory pattern %q: %v", pattern, err)
+		}
+
+		isGlob := strings.ContainsAny(pattern, "*?[")
+		if len(matches) == 0 && !isGlob {
+			return nil, fmt.Errorf("App directory %s does not exist", pattern)
+		}
+
+		for _, dir := range matches {
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+				continue
+			}
+
+			if found, _ := exists(filepath.Join(dir, "package.json")); isGlob && !found {
+				continue
+			}
+
+			if !seen[dir] {
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// EnvVarsFor retrieves the env vars of the given environment as sorted "KEY=VAL" pairs,
+// environment specific values take precedence over the top level ones
+func (m *Manifest) EnvVarsFor(env string) []string {
+	vars := map[string]string{}
+	for k, v := range m.EnvVars {
+		vars[k] = v
+	}
+
+	if e, ok := m.Environments[env]; ok {
+		for k, v := range e.EnvVars {
+			vars[k] = v
+		}
+	}
+
+	return sortedPairs(vars)
+}
+
+// EdgeConfigsFor retrieves the edge config refs of the given environment as sorted "NAME=config:key" pairs
+func (m *Manifest) EdgeConfigsFor(env string) []string {
+	if e, ok := m.Environments[env]; ok {
+		return sortedPairs(e.EdgeConfigs)
+	}
+
+	return []string{}
+}
+
+// ReplicasFor retrieves the replica count of the given environment, 0 if none was declared
+func (m *Manifest) ReplicasFor(env string) int {
+	if e, ok := m.Environments[env]; ok && e.Replicas > 0 {
+		return e.Replicas
+	}
+
+	return m.Replicas
+}
+
+func sortedPairs(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+values[k])
+	}
+
+	return pairs
+}
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// RuntimeCacheFileName name of the file caching the runtime catalog of each cluster
+const RuntimeCacheFileName = "runtimes"
+
+// Runtime an application runtime and the versions of it a cluster can build
+type Runtime struct {
+	Name     string   `json:"name" yaml:"name"`
+	Versions []string `json:"versions" yaml:"versions"`
+	Default  string   `json:"default" yaml:"default"`
+}
+
+// RuntimeCatalog the runtimes supported by a cluster
+type RuntimeCatalog []Runtime
+
+// EmbeddedRuntimes the runtimes known to this build of shipyardctl, used when
+// the cluster catalog cannot be fetched and was never cached
+var EmbeddedRuntimes = RuntimeCatalog{
+	{Name: "node", Versions: []string{"4", "5", "6"}, Default: "4"},
+}
+
+// cachedCatalog a runtime catalog and when it was fetched
+type cachedCatalog struct {
+	Fetched time.Time
+	Catalog RuntimeCatalog
+}
+
+// Validate checks both the name and optional version of a "name[:version]" runtime
+func (c RuntimeCatalog) Validate(runtime string) error {
+	split := strings.SplitN(runtime, ":", 2)
+
+	for _, r := range c {
+		if r.Name != split[0] {
+			continue
+		}
+
+		if len(split) < 2 {
+			return nil // the default version is used
+		}
+
+		for _, v := range r.Versions {
+			if v == split[1] {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("Unsupported %s version: \"%s\". Supported versions: %s", r.Name, split[1], strings.Join(r.Versions, ", "))
+	}
+
+	return fmt.Errorf("Unsupported runtime: \"%s\". Supported runtimes: %s", split[0], strings.Join(c.Names(), ", "))
+}
+
+// Names lists the names of the runtimes in the catalog
+func (c RuntimeCatalog) Names() []string {
+	names := make([]string, 0, len(c))
+	for _, r := range c {
+		names = append(names, r.Name)
+	}
+
+	return names
+}
+
+// LoadCachedRuntimes retrieves the last catalog fetched from the given cluster
+// and when it was fetched, or a nil catalog if there is none
+func LoadCachedRuntimes(cluster string) (RuntimeCatalog, time.Time, error) {
+	cache, err := loadRuntimeCache()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	entry, ok := cache[cluster]
+	if !ok {
+		return nil, time.Time{}, nil
+	}
+
+	return entry.Catalog, entry.Fetched, nil
+}
+
+// SaveCachedRuntimes stores the catalog fetched from the given cluster
+func SaveCachedRuntimes(cluster string, catalog RuntimeCatalog) error {
+	cache, err := loadRuntimeCache()
+	if err != nil {
+		cache = map[string]cachedCatalog{} // replace an unreadable cache
+	}
+
+	cache[cluster] = cachedCatalog{time.Now(), catalog}
+
+	data, err := yaml.Marshal(cache)
+	if err != nil {
+		return err
+	}
+
+	dir, err := ConfigDir()
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, RuntimeCacheFileName), data, 0644)
+}
+
+func loadRuntimeCache() (map[string]cachedCatalog, error) {
+	home, err := homedir()
+	if err != nil {
+		return nil, err
+	}
+
+	cache := map[string]cachedCatalog{}
+	data, err := ioutil.ReadFile(filepath.Join(home, ShipyardctlConfigDir, RuntimeCacheFileName))
+	if os.IsNotExist(err) {
+		return cache, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return cache, yaml.Unmarshal(data, &cache)
+}
@@ -0,0 +1,212 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version a major.minor.patch semantic version, pre-release tags are not considered
+type Version [3]int
+
+var maxVersion = Version{1 << 30, 0, 0}
+
+// versionRange the half open interval of versions [Lo, Hi)
+type versionRange struct {
+	Lo Version
+	Hi Version
+}
+
+// ParseVersion parses a full or partial version, such as "4", "4.2" or "v4.2.1".
+// It returns the number of parts given, x or * parts end the version.
+func ParseVersion(s string) (Version, int, error) {
+	v := Version{}
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	s = strings.TrimPrefix(s, "=")
+
+	// drop pre-release and build metadata
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+
+	if s == "" {
+		return v, 0, nil
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return v, 0, fmt.Errorf("Invalid version: %s", s)
+	}
+
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			return v, i, nil
+		}
+
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, 0, fmt.Errorf("Invalid version: %s", s)
+		}
+
+		v[i] = n
+	}
+
+	return v, len(parts), nil
+}
+
+// RangeAllows reports whether the npm style semver range admits any version
+// matching the given partial version, e.g. whether ">=4.2 <6" admits some "4" or "5.1"
+func RangeAllows(rng string, version string) (bool, error) {
+	v, n, err := ParseVersion(version)
+	if err != nil {
+		return false, err
+	}
+
+	target := partialRange(v, n)
+
+	for _, alt := range strings.Split(rng, "||") {
+		r, err := parseComparatorSet(alt)
+		if err != nil {
+			return false, err
+		}
+
+		if later(r.Lo, target.Lo).Less(earlier(r.Hi, target.Hi)) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Less reports whether v precedes o
+func (v Version) Less(o Version) bool {
+	for i := range v {
+		if v[i] != o[i] {
+			return v[i] < o[i]
+		}
+	}
+
+	return false
+}
+
+// String formats the version as major.minor.patch
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+}
+
+func parseComparatorSet(set string) (versionRange, error) {
+	r := versionRange{Version{}, maxVersion}
+	fields := strings.Fields(set)
+
+	// hyphen ranges, "1.2.3 - 2.3"
+	if len(fields) == 3 && fields[1] == "-" {
+		lo, n, err := ParseVersion(fields[0])
+		if err != nil {
+			return r, err
+		}
+
+		hi, m, err := ParseVersion(fields[2])
+		if err != nil {
+			return r, err
+		}
+
+		return versionRange{partialRange(lo, n).Lo, partialRange(hi, m).Hi}, nil
+	}
+
+	for i := 0; i < len(fields); i++ {
+		comp := fields[i]
+
+		// allow a space between the operator and version, ">= 4"
+		if strings.TrimLeft(comp, "<>=^~") == "" && i+1 < len(fields) {
+			comp += fields[i+1]
+			i++
+		}
+
+		c, err := parseComparator(comp)
+		if err != nil {
+			return r, err
+		}
+
+		r.Lo = later(r.Lo, c.Lo)
+		r.Hi = earlier(r.Hi, c.Hi)
+	}
+
+	return r, nil
+}
+
+func parseComparator(comp string) (versionRange, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(comp, prefix) {
+			op = prefix
+			break
+		}
+	}
+
+	v, n, err := ParseVersion(strings.TrimPrefix(comp, op))
+	if err != nil {
+		return versionRange{}, err
+	}
+
+	p := partialRange(v, n)
+
+	switch op {
+	case ">=":
+		return versionRange{p.Lo, maxVersion}, nil
+	case ">":
+		return versionRange{p.Hi, maxVersion}, nil
+	case "<=":
+		return versionRange{Version{}, p.Hi}, nil
+	case "<":
+		return versionRange{Version{}, p.Lo}, nil
+	case "^":
+		switch {
+		case n == 0:
+			return p, nil
+		case v[0] > 0 || n == 1:
+			return versionRange{v, Version{v[0] + 1, 0, 0}}, nil
+		case v[1] > 0 || n == 2:
+			return versionRange{v, Version{0, v[1] + 1, 0}}, nil
+		default:
+			return versionRange{v, Version{0, 0, v[2] + 1}}, nil
+		}
+	case "~":
+		if n <= 1 {
+			return p, nil
+		}
+
+		return versionRange{v, Version{v[0], v[1] + 1, 0}}, nil
+	default:
+		return p, nil
+	}
+}
+
+// partialRange the versions matching a partial version, "4" is [4.0.0, 5.0.0)
+func partialRange(v Version, n int) versionRange {
+	switch n {
+	case 0:
+		return versionRange{Version{}, maxVersion}
+	case 1:
+		return versionRange{v, Version{v[0] + 1, 0, 0}}
+	case 2:
+		return versionRange{v, Version{v[0], v[1] + 1, 0}}
+	default:
+		return versionRange{v, Version{v[0], v[1], v[2] + 1}}
+	}
+}
+
+func later(a, b Version) Version {
+	if a.Less(b) {
+		return b
+	}
+
+	return a
+}
+
+func earlier(a, b Version) Version {
+	if a.Less(b) {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,115 @@
+package utils
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	cases := []struct {
+		in    string
+		want  Version
+		parts int
+	}{
+		{"", Version{}, 0},
+		{"4", Version{4, 0, 0}, 1},
+		{"v4.2", Version{4, 2, 0}, 2},
+		{"=4.2.1", Version{4, 2, 1}, 3},
+		{"4.2.1-beta.1+build", Version{4, 2, 1}, 3},
+		{"4.x", Version{4, 0, 0}, 1},
+		{"4.2.*", Version{4, 2, 0}, 2},
+		{"X", Version{}, 0},
+	}
+
+	for _, c := range cases {
+		v, n, err := ParseVersion(c.in)
+		if err != nil {
+			t.Errorf("ParseVersion(%q) failed: %v", c.in, err)
+			continue
+		}
+
+		if v != c.want || n != c.parts {
+			t.Errorf("ParseVersion(%q) = %v, %d, want %v, %d", c.in, v, n, c.want, c.parts)
+		}
+	}
+
+	for _, in := range []string{"a", "4.b", "1.2.3.4"} {
+		if _, _, err := ParseVersion(in); err == nil {
+			t.Errorf("ParseVersion(%q) should fail", in)
+		}
+	}
+}
+
+func TestRangeAllows(t *testing.T) {
+	cases := []struct {
+		rng     string
+		version string
+		want    bool
+	}{
+		// caret
+		{"^4.2.0", "4", true},
+		{"^4.2.0", "4.1", false},
+		{"^4.2.0", "4.9.1", true},
+		{"^4.2.0", "5", false},
+		{"^0.2.3", "0.2", true},
+		{"^0.2.3", "0.3", false},
+		{"^0.0.3", "0.0.3", true},
+		{"^0.0.3", "0.0.4", false},
+		{"^0", "0.9", true},
+		{"^0", "1", false},
+
+		// tilde
+		{"~4.2.1", "4.2", true},
+		{"~4.2.1", "4.2.0", false},
+		{"~4.2.1", "4.3", false},
+		{"~4", "4.9", true},
+		{"~4", "5", false},
+
+		// x ranges
+		{"4.x", "4.5.1", true},
+		{"4.x", "5", false},
+		{"4.2.x", "4.2.9", true},
+		{"4.2.x", "4.3", false},
+		{"*", "8", true},
+		{"", "8", true},
+
+		// alternatives
+		{"^4 || ^6", "6.1", true},
+		{"^4 || ^6", "5", false},
+		{"4.2.x||>=8", "9", true},
+
+		// hyphen ranges
+		{"4.2 - 6", "6.9.9", true},
+		{"4.2 - 6", "7", false},
+		{"4.2 - 6", "4.1", false},
+		{"4.2.1 - 4.2.3", "4.2.3", true},
+		{"4.2.1 - 4.2.3", "4.2.4", false},
+
+		// comparators
+		{">=4.2 <6", "5.1", true},
+		{">=4.2 <6", "6", false},
+		{">=4.2 <6", "4", true},
+		{"> 4", "4.9", false},
+		{"> 4", "5", true},
+		{">4.2.1", "4.2.1", false},
+		{"<= 4", "4.9.9", true},
+		{"<4", "4", false},
+		{"4.2.1", "4", true},
+		{"=4.2.1", "4.2.2", false},
+	}
+
+	for _, c := range cases {
+		got, err := RangeAllows(c.rng, c.version)
+		if err != nil {
+			t.Errorf("RangeAllows(%q, %q) failed: %v", c.rng, c.version, err)
+			continue
+		}
+
+		if got != c.want {
+			t.Errorf("RangeAllows(%q, %q) = %v, want %v", c.rng, c.version, got, c.want)
+		}
+	}
+
+	for _, c := range [][2]string{{"^a", "4"}, {"^4", "1.2.3.4"}, {"latest", "4"}, {"git+https://x/y.git", "1"}} {
+		if _, err := RangeAllows(c[0], c[1]); err == nil {
+			t.Errorf("RangeAllows(%q, %q) should fail", c[0], c[1])
+		}
+	}
+}
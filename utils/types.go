@@ -19,10 +19,17 @@ type Context struct {
   ClusterInfo Cluster
   UserInfo User
   ProxyMgmtApi string
+  MaxArchiveSize string `yaml:"maxarchivesize,omitempty"` // largest app archive to import, ex. 50MB
 }
 
 // Config shipyardctl configuration object
 type Config struct {
   CurrentContext string // name of current Context
   Contexts []Context
+  History *HistoryRetention `yaml:"history,omitempty"` // limits on saved build and deploy output
+}
+
+// sharedContexts format of exported contexts
+type sharedContexts struct {
+  Contexts []Context
 }
\ No newline at end of file